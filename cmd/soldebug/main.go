@@ -0,0 +1,107 @@
+// soldebug is an interactive step debugger of the solidity contracts over a local state file
+//
+// Usage:
+//
+//	soldebug -state state.json -from <address> -to <address> -input <hex>
+//	soldebug -state state.json -from <address> -to <address> -create -input <code hex>
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/solidity"
+	"github.com/fletaio/solidity/vm"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		statePath = flag.String("state", "", "path of the state file")
+		fromStr   = flag.String("from", "", "address of the sender")
+		toStr     = flag.String("to", "", "address of the contract")
+		inputStr  = flag.String("input", "", "hex of the call input or the creation code")
+		valueStr  = flag.String("value", "0", "amount which is sent to the contract")
+		create    = flag.Bool("create", false, "create the contract at the address of the -to")
+		name      = flag.String("name", "", "name of the created contract")
+		save      = flag.Bool("save", false, "save the state when the execution succeeds")
+		forkName  = flag.String("fork", vm.LatestFork.String(), "fork of the rules of the execution")
+	)
+	flag.Parse()
+
+	if len(*statePath) == 0 {
+		flag.Usage()
+		return fmt.Errorf("state file is required")
+	}
+	st, err := LoadLocalState(*statePath)
+	if err != nil {
+		return err
+	}
+	from, err := common.ParseAddress(*fromStr)
+	if err != nil {
+		return err
+	}
+	to, err := common.ParseAddress(*toStr)
+	if err != nil {
+		return err
+	}
+	input, err := hex.DecodeString(*inputStr)
+	if err != nil {
+		return err
+	}
+	value, err := amount.ParseAmount(*valueStr)
+	if err != nil {
+		return err
+	}
+	fork, err := vm.ParseFork(*forkName)
+	if err != nil {
+		return err
+	}
+	rules := vm.NewRules(fork)
+
+	debugger := vm.NewDebugger(vm.NewTerminalSession(os.Stdin, os.Stdout))
+	vctx := vm.Context{
+		CanTransfer:      solidity.CanTransfer,
+		Transfer:         solidity.Transfer,
+		GetHash:          func(uint64) hash.Hash256 { return hash.Hash256{} },
+		GetAddressByName: st.AddressByName,
+		Origin:           from,
+		BlockNumber:      new(big.Int).SetUint64(100),
+		Time:             big.NewInt(time.Now().Unix()),
+		Difficulty:       new(big.Int),
+	}
+	evm := vm.NewEVM(vctx, st, vm.Config{
+		Debug:  true,
+		Tracer: debugger,
+		Rules:  &rules,
+	})
+
+	var ret []byte
+	if *create {
+		ret, err = evm.Create(vm.AccountRef(from), to, *name, input, value)
+	} else {
+		ret, err = evm.Call(vm.AccountRef(from), to, input, value)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("0x%x\n", ret)
+
+	if *save {
+		return st.Save(*statePath)
+	}
+	return nil
+}
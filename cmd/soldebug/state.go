@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/solidity/vm"
+)
+
+// stateAccount is an account of the local state
+type stateAccount struct {
+	Name     string
+	Balance  *amount.Amount
+	Seq      uint64
+	Code     []byte
+	Storage  map[hash.Hash256]hash.Hash256
+	Suicided bool
+}
+
+// journalEntry reverts a change of the local state
+type journalEntry func()
+
+// jsonAccount is the form of the account in the state file
+type jsonAccount struct {
+	Name    string            `json:"name,omitempty"`
+	Balance string            `json:"balance"`
+	Seq     uint64            `json:"seq,omitempty"`
+	Code    string            `json:"code,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+}
+
+// LocalState is a StateDB which keeps the accounts in memory and is loaded from the state file
+// The state file is a JSON object of the accounts by the address
+// The changes are journaled while a snapshot is taken, so the snapshot doesn't copy the accounts
+type LocalState struct {
+	accounts  map[common.Address]*stateAccount
+	journal   []journalEntry
+	snapshots []int
+}
+
+// LoadLocalState returns the LocalState of the state file
+func LoadLocalState(path string) (*LocalState, error) {
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jaccs map[string]*jsonAccount
+	if err := json.Unmarshal(bs, &jaccs); err != nil {
+		return nil, err
+	}
+	st := &LocalState{
+		accounts: map[common.Address]*stateAccount{},
+	}
+	for k, jacc := range jaccs {
+		addr, err := common.ParseAddress(k)
+		if err != nil {
+			return nil, err
+		}
+		acc := &stateAccount{
+			Name:    jacc.Name,
+			Seq:     jacc.Seq,
+			Storage: map[hash.Hash256]hash.Hash256{},
+		}
+		if len(jacc.Balance) > 0 {
+			if acc.Balance, err = amount.ParseAmount(jacc.Balance); err != nil {
+				return nil, err
+			}
+		} else {
+			acc.Balance = amount.NewCoinAmount(0, 0)
+		}
+		if acc.Code, err = hex.DecodeString(jacc.Code); err != nil {
+			return nil, err
+		}
+		for sk, sv := range jacc.Storage {
+			key, err := hash.ParseHash(sk)
+			if err != nil {
+				return nil, err
+			}
+			value, err := hash.ParseHash(sv)
+			if err != nil {
+				return nil, err
+			}
+			acc.Storage[key] = value
+		}
+		st.accounts[addr] = acc
+	}
+	return st, nil
+}
+
+// Save writes the accounts to the state file
+func (st *LocalState) Save(path string) error {
+	jaccs := make(map[string]*jsonAccount, len(st.accounts))
+	for addr, acc := range st.accounts {
+		if acc.Suicided {
+			continue
+		}
+		jacc := &jsonAccount{
+			Name:    acc.Name,
+			Balance: acc.Balance.String(),
+			Seq:     acc.Seq,
+			Code:    hex.EncodeToString(acc.Code),
+			Storage: make(map[string]string, len(acc.Storage)),
+		}
+		for k, v := range acc.Storage {
+			jacc.Storage[k.String()] = v.String()
+		}
+		jaccs[addr.String()] = jacc
+	}
+	bs, err := json.MarshalIndent(jaccs, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, bs, 0644)
+}
+
+// AddressByName returns the address of the account which has the name
+func (st *LocalState) AddressByName(name string) (common.Address, error) {
+	for addr, acc := range st.accounts {
+		if acc.Name == name {
+			return addr, nil
+		}
+	}
+	return common.Address{}, vm.ErrNotExistContract
+}
+
+func (st *LocalState) account(addr common.Address) *stateAccount {
+	acc, has := st.accounts[addr]
+	if !has {
+		acc = &stateAccount{
+			Balance: amount.NewCoinAmount(0, 0),
+			Storage: map[hash.Hash256]hash.Hash256{},
+		}
+		st.accounts[addr] = acc
+		st.record(func() { delete(st.accounts, addr) })
+	}
+	return acc
+}
+
+// record appends the entry which reverts the change to the journal if there is a snapshot
+func (st *LocalState) record(e journalEntry) {
+	if len(st.snapshots) > 0 {
+		st.journal = append(st.journal, e)
+	}
+}
+
+// CreateAccount creates the account of the address
+func (st *LocalState) CreateAccount(addr common.Address, name string) {
+	acc := st.account(addr)
+	prev := acc.Name
+	st.record(func() { acc.Name = prev })
+	acc.Name = name
+}
+
+// SubBalance subtracts the amount from the balance of the address
+func (st *LocalState) SubBalance(addr common.Address, b *amount.Amount) {
+	acc := st.account(addr)
+	prev := acc.Balance
+	st.record(func() { acc.Balance = prev })
+	acc.Balance = acc.Balance.Sub(b)
+}
+
+// AddBalance adds the amount to the balance of the address
+func (st *LocalState) AddBalance(addr common.Address, b *amount.Amount) {
+	acc := st.account(addr)
+	prev := acc.Balance
+	st.record(func() { acc.Balance = prev })
+	acc.Balance = acc.Balance.Add(b)
+}
+
+// GetBalance returns the balance of the address
+func (st *LocalState) GetBalance(addr common.Address) *amount.Amount {
+	if acc, has := st.accounts[addr]; has {
+		return acc.Balance
+	}
+	return amount.NewCoinAmount(0, 0)
+}
+
+// GetSeq returns the sequence of the address
+func (st *LocalState) GetSeq(addr common.Address) uint64 {
+	if acc, has := st.accounts[addr]; has {
+		return acc.Seq
+	}
+	return 0
+}
+
+// AddSeq increases the sequence of the address
+func (st *LocalState) AddSeq(addr common.Address) {
+	acc := st.account(addr)
+	st.record(func() { acc.Seq-- })
+	acc.Seq++
+}
+
+// GetCodeHash returns the code hash of the address
+func (st *LocalState) GetCodeHash(addr common.Address) hash.Hash256 {
+	if acc, has := st.accounts[addr]; has && len(acc.Code) > 0 {
+		return hash.Hash(acc.Code)
+	}
+	return hash.Hash256{}
+}
+
+// GetCode returns the code of the address
+func (st *LocalState) GetCode(addr common.Address) []byte {
+	if acc, has := st.accounts[addr]; has {
+		return acc.Code
+	}
+	return nil
+}
+
+// SetCode sets the code of the address
+func (st *LocalState) SetCode(addr common.Address, code []byte) {
+	acc := st.account(addr)
+	prev := acc.Code
+	st.record(func() { acc.Code = prev })
+	acc.Code = code
+}
+
+// GetCodeSize returns the code size of the address
+func (st *LocalState) GetCodeSize(addr common.Address) int {
+	return len(st.GetCode(addr))
+}
+
+// GetState returns the storage value of the address
+func (st *LocalState) GetState(addr common.Address, h hash.Hash256) hash.Hash256 {
+	if acc, has := st.accounts[addr]; has {
+		return acc.Storage[h]
+	}
+	return hash.Hash256{}
+}
+
+// SetState sets the storage value of the address
+func (st *LocalState) SetState(addr common.Address, h hash.Hash256, v hash.Hash256) {
+	acc := st.account(addr)
+	prev, had := acc.Storage[h]
+	st.record(func() {
+		if had {
+			acc.Storage[h] = prev
+		} else {
+			delete(acc.Storage, h)
+		}
+	})
+	if v == (hash.Hash256{}) {
+		delete(acc.Storage, h)
+	} else {
+		acc.Storage[h] = v
+	}
+}
+
+// Suicide marks the account of the address as suicided
+func (st *LocalState) Suicide(addr common.Address) bool {
+	acc, has := st.accounts[addr]
+	if !has {
+		return false
+	}
+	prev := acc.Suicided
+	st.record(func() { acc.Suicided = prev })
+	acc.Suicided = true
+	return true
+}
+
+// HasSuicided checks the dead state of the address
+func (st *LocalState) HasSuicided(addr common.Address) bool {
+	acc, has := st.accounts[addr]
+	return has && acc.Suicided
+}
+
+// PayRent doesn't charge the rent in the local state
+func (st *LocalState) PayRent(addr common.Address) error {
+	return nil
+}
+
+// Exist checks that the account of the address is exist or not
+func (st *LocalState) Exist(addr common.Address) bool {
+	_, has := st.accounts[addr]
+	return has
+}
+
+// Empty checks that seq == 0, balance == 0, code size == 0
+func (st *LocalState) Empty(addr common.Address) bool {
+	acc, has := st.accounts[addr]
+	return !has || (acc.Seq == 0 && acc.Balance.IsZero() && len(acc.Code) == 0)
+}
+
+// RevertToSnapshot restores the accounts to the snapshot by reverting the journaled changes
+func (st *LocalState) RevertToSnapshot(n int) {
+	if n >= len(st.snapshots) {
+		return
+	}
+	at := st.snapshots[n]
+	for i := len(st.journal) - 1; i >= at; i-- {
+		st.journal[i]()
+	}
+	st.journal = st.journal[:at]
+	st.snapshots = st.snapshots[:n]
+}
+
+// CommitSnapshot keeps the changes after the snapshot
+func (st *LocalState) CommitSnapshot(n int) {
+	if n >= len(st.snapshots) {
+		return
+	}
+	st.snapshots = st.snapshots[:n]
+	if n == 0 {
+		st.journal = nil
+	}
+}
+
+// Snapshot returns the id of the snapshot which is the position of the journal
+func (st *LocalState) Snapshot() int {
+	st.snapshots = append(st.snapshots, len(st.journal))
+	return len(st.snapshots) - 1
+}
+
+// AddLog prints the log
+func (st *LocalState) AddLog(l *vm.Log) {
+	log.Printf("log %s topics=%v data=%x\n", l.Address.String(), l.Topics, l.Data)
+}
@@ -0,0 +1,80 @@
+// soldisasm prints the disassembly of the contract code
+//
+// Usage:
+//
+//	soldisasm <file of the code hex>
+//	soldisasm < <file of the code hex>
+//	soldisasm -cfg dot|json <file of the code hex>
+//	soldisasm -fork Byzantium <file of the code hex>
+//
+// The -cfg prints the control flow graph of the runtime code instead of the listing
+// The -fork selects the instruction set which decides the valid opcodes (the latest fork by default)
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/fletaio/solidity/vm"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg := flag.String("cfg", "", "print the control flow graph in the format (dot or json)")
+	forkName := flag.String("fork", vm.LatestFork.String(), "the fork of the instruction set")
+	flag.Parse()
+
+	fork, err := vm.ParseFork(*forkName)
+	if err != nil {
+		return err
+	}
+	rules := vm.NewRules(fork)
+
+	var bs []byte
+	if flag.NArg() > 0 {
+		bs, err = ioutil.ReadFile(flag.Arg(0))
+	} else {
+		bs, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return err
+	}
+	code, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(bs)), "0x"))
+	if err != nil {
+		return err
+	}
+	d := vm.DisassembleContract(code, &rules)
+	if len(*cfg) == 0 {
+		_, err = d.WriteTo(os.Stdout)
+		return err
+	}
+
+	runtime := code[d.RuntimeOffset : len(code)-len(d.ConstructorArgs)]
+	runtime = runtime[:len(runtime)-len(d.Metadata)]
+	g := vm.BuildControlFlowGraph(runtime, &rules)
+	switch *cfg {
+	case "dot":
+		_, err = g.WriteDOT(os.Stdout)
+		return err
+	case "json":
+		bs, err := json.MarshalIndent(g, "", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(bs))
+		return nil
+	default:
+		return fmt.Errorf("unknown cfg format %s", *cfg)
+	}
+}
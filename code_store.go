@@ -0,0 +1,72 @@
+package solidity
+
+import (
+	"encoding/binary"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/solidity/vm"
+)
+
+// CodeStoreAddress is the address that holds the shared code store
+// The code is stored once by its code hash and accounts hold only the code hash
+// It is in the reserved coordinate, so it cannot be an address of an account
+var CodeStoreAddress = vm.ReservedAddress(0x434F444553544F52) // "CODESTOR"
+
+func sharedCodeKey(h hash.Hash256) []byte {
+	k := hash.Hash(append([]byte("__SHAREDCODE__"), h[:]...))
+	return k[:]
+}
+
+func sharedCodeRefKey(h hash.Hash256) []byte {
+	k := hash.Hash(append([]byte("__SHAREDCODEREF__"), h[:]...))
+	return k[:]
+}
+
+// loadCode returns the code of the address from the shared code store
+// It falls back to the code of the account that is not migrated yet
+func loadCode(loader accountDataLoader, addr common.Address) []byte {
+	var h hash.Hash256
+	bs := loader.AccountData(addr, KeywordCodeHash[:])
+	if len(bs) > 0 {
+		copy(h[:], bs)
+		if code := loader.AccountData(CodeStoreAddress, sharedCodeKey(h)); len(code) > 0 {
+			return code
+		}
+	}
+	return loader.AccountData(addr, KeywordCode[:])
+}
+
+// codeRefCount returns the number of accounts which refer the code of the hash
+func codeRefCount(loader accountDataLoader, h hash.Hash256) uint32 {
+	bs := loader.AccountData(CodeStoreAddress, sharedCodeRefKey(h))
+	if len(bs) == 4 {
+		return binary.LittleEndian.Uint32(bs)
+	}
+	return 0
+}
+
+// retainCode stores the code to the shared code store or increases the reference count of it
+func retainCode(ctx *data.Context, h hash.Hash256, code []byte) {
+	cnt := codeRefCount(ctx, h)
+	if cnt == 0 {
+		ctx.SetAccountData(CodeStoreAddress, sharedCodeKey(h), code)
+	}
+	bs := make([]byte, 4)
+	binary.LittleEndian.PutUint32(bs, cnt+1)
+	ctx.SetAccountData(CodeStoreAddress, sharedCodeRefKey(h), bs)
+}
+
+// releaseCode decreases the reference count of the code and deletes it when nobody refers it
+func releaseCode(ctx *data.Context, h hash.Hash256) {
+	cnt := codeRefCount(ctx, h)
+	if cnt <= 1 {
+		ctx.SetAccountData(CodeStoreAddress, sharedCodeKey(h), nil)
+		ctx.SetAccountData(CodeStoreAddress, sharedCodeRefKey(h), nil)
+		return
+	}
+	bs := make([]byte, 4)
+	binary.LittleEndian.PutUint32(bs, cnt-1)
+	ctx.SetAccountData(CodeStoreAddress, sharedCodeRefKey(h), bs)
+}
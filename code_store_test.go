@@ -0,0 +1,95 @@
+package solidity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/solidity/vm"
+)
+
+func TestCodeRefCount(t *testing.T) {
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(2), 0)
+	ctx := data.NewContext(ld)
+	runtime := storeCode
+	a, err := createContract(t, ctx, testUser(2), 1, runtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := createContract(t, ctx, testUser(2), 2, runtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := hash.Hash(runtime)
+	if cnt := codeRefCount(ctx, h); cnt != 2 {
+		t.Fatalf("got the reference count %d of the shared code, want 2", cnt)
+	}
+	if code := loadCode(ctx, b); !bytes.Equal(code, runtime) {
+		t.Fatalf("got the code %x, want %x", code, runtime)
+	}
+
+	sd := &StateDB{Context: ctx, Coord: common.NewCoordinate(1, 0)}
+	other := revertCode
+	sd.SetCode(a, other)
+	if cnt := codeRefCount(ctx, h); cnt != 1 {
+		t.Fatalf("got the reference count %d of the replaced code, want 1", cnt)
+	}
+	if cnt := codeRefCount(ctx, hash.Hash(other)); cnt != 1 {
+		t.Fatalf("got the reference count %d of the new code, want 1", cnt)
+	}
+
+	sd.Suicide(b)
+	if err := sd.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+	if cnt := codeRefCount(ctx, h); cnt != 0 {
+		t.Fatalf("got the reference count %d of the released code, want 0", cnt)
+	}
+	if code := ctx.AccountData(CodeStoreAddress, sharedCodeKey(h)); len(code) > 0 {
+		t.Fatal("the released code is left in the shared code store")
+	}
+}
+
+func TestNestedLegacyCallee(t *testing.T) {
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ld.addAccount(testUser(2), 0)
+	runtime := storeCode
+	legacy := ld.addLegacyContract(common.NewCoordinate(5, 0), "", runtime)
+	ctx := data.NewContext(ld)
+
+	// the caller calls the legacy contract, so the legacy contract is not called by the transaction directly
+	callCode := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH14)}
+	callCode = append(callCode, legacy[:]...)
+	callCode = append(callCode, byte(vm.PUSH1), 0, byte(vm.CALL), byte(vm.STOP))
+	caller, err := createContract(t, ctx, testUser(2), 1, callCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := (&ViewDB{Loader: ld}).GetCode(legacy); !bytes.Equal(code, runtime) {
+		t.Fatalf("got the legacy code %x from the ViewDB, want %x", code, runtime)
+	}
+	if err := callAt(t, ctx, testUser(1), caller, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := (&StateDB{Context: ctx}).GetState(legacy, hash.Hash256{}); v == (hash.Hash256{}) {
+		t.Fatal("the nested legacy callee is not executed")
+	}
+	if code := ctx.AccountData(legacy, KeywordCode[:]); len(code) > 0 {
+		t.Fatal("the code of the nested legacy callee is not migrated")
+	}
+	h := hash.Hash(runtime)
+	if bs := ctx.AccountData(legacy, KeywordCodeHash[:]); !bytes.Equal(bs, h[:]) {
+		t.Fatalf("got the code hash %x, want %x", bs, h)
+	}
+	if cnt := codeRefCount(ctx, h); cnt != 1 {
+		t.Fatalf("got the reference count %d of the migrated code, want 1", cnt)
+	}
+	if code := loadCode(ctx, legacy); !bytes.Equal(code, runtime) {
+		t.Fatalf("got the migrated code %x, want %x", code, runtime)
+	}
+}
@@ -6,12 +6,23 @@ import (
 
 // solidity errors
 var (
-	ErrExistAddress        = errors.New("exist address")
-	ErrExistAccountName    = errors.New("exist account name")
-	ErrInvalidAccountName  = errors.New("invaild account name")
-	ErrInvalidSequence     = errors.New("invalid sequence")
-	ErrInsuffcientBalance  = errors.New("insufficient balance")
-	ErrVirtualMachinePanic = errors.New("virtual machine panic")
-	ErrInvalidSignerCount  = errors.New("invalid signer count")
-	ErrNotAllowed          = errors.New("not allowed")
+	ErrExistAddress           = errors.New("exist address")
+	ErrExistAccountName       = errors.New("exist account name")
+	ErrInvalidAccountName     = errors.New("invaild account name")
+	ErrInvalidSequence        = errors.New("invalid sequence")
+	ErrInsuffcientBalance     = errors.New("insufficient balance")
+	ErrInvalidAmount          = errors.New("invalid amount")
+	ErrVirtualMachinePanic    = errors.New("virtual machine panic")
+	ErrInvalidSignerCount     = errors.New("invalid signer count")
+	ErrNotAllowed             = errors.New("not allowed")
+	ErrDuplicatedSigner       = errors.New("duplicated signer")
+	ErrInvalidSigner          = errors.New("invalid signer")
+	ErrInvalidCallCount       = errors.New("invalid call count")
+	ErrDestroyedAddress       = errors.New("destroyed address")
+	ErrDormantContract        = errors.New("dormant contract")
+	ErrRentOverflow           = errors.New("rent overflow")
+	ErrExecutionTimeout       = errors.New("execution timeout")
+	ErrInvalidExecutionConfig = errors.New("invalid execution config")
+	ErrNotContractTransaction = errors.New("not contract transaction")
+	ErrInvalidTxIndex         = errors.New("invalid tx index")
 )
@@ -0,0 +1,143 @@
+package solidity
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/solidity/vm"
+)
+
+// DefaultMaxSteps is the default maximum number of the executed opcodes of a transaction
+const DefaultMaxSteps = 10000000
+
+// DefaultTxTimeout is the default deadline of the execution of a transaction
+const DefaultTxTimeout = 5 * time.Second
+
+// ExecutionConfig is the configuration of the contract execution which is given to the EVM
+type ExecutionConfig struct {
+	// Limits has the resource limits of the execution by the Max* fields of it
+	Limits vm.Config
+	// TxTimeout is the deadline of the execution of a transaction (0 means no deadline)
+	// The MaxSteps bounds the execution deterministically and the deadline guards the node
+	// when the execution is slower than expected, so it should be long enough to run the MaxSteps
+	TxTimeout time.Duration
+	// Chain has the fork activation heights which select the rules of the execution
+	Chain *vm.ChainConfig
+}
+
+// DefaultExecutionConfig returns the configuration which has the DefaultMaxSteps and the DefaultTxTimeout
+func DefaultExecutionConfig() *ExecutionConfig {
+	return &ExecutionConfig{
+		Limits: vm.Config{
+			MaxSteps: DefaultMaxSteps,
+		},
+		TxTimeout: DefaultTxTimeout,
+		Chain:     vm.DefaultChainConfig(),
+	}
+}
+
+var execConfig = DefaultExecutionConfig()
+
+// SetExecutionConfig sets the configuration of the contract execution
+func SetExecutionConfig(cfg *ExecutionConfig) error {
+	if cfg.Chain == nil {
+		return ErrInvalidExecutionConfig
+	}
+	if err := cfg.Chain.Validate(); err != nil {
+		return err
+	}
+	execConfig = cfg
+	return nil
+}
+
+// SetResourceLimits sets the resource limits of the contract execution by the Max* fields of the cfg
+// The MaxSteps bounds the execution of a transaction deterministically, so every node rejects the same transactions
+func SetResourceLimits(cfg vm.Config) {
+	c := *execConfig
+	c.Limits = cfg
+	execConfig = &c
+}
+
+// SetChainConfig sets the fork activation heights which select the rules of the contract execution,
+// the code policies which validate the deployed code from their heights
+// and the chain specific precompiled contracts which are registered by the RegisterPrecompile of it
+func SetChainConfig(c *vm.ChainConfig) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	cfg := *execConfig
+	cfg.Chain = c
+	execConfig = &cfg
+	return nil
+}
+
+// txContext returns the context which has the deadline of the execution of a transaction
+func (cfg *ExecutionConfig) txContext() (context.Context, context.CancelFunc) {
+	if cfg.TxTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), cfg.TxTimeout)
+}
+
+// newEVM returns the EVM which executes the contracts of the loader on behalf of the origin
+// The rules of the execution are the rules of the fork and the code policy which are activated at the height
+// The execution is traced by the tracer when it is not nil
+func newEVM(cfg *ExecutionConfig, loader data.Loader, statedb vm.StateDB, height uint32, origin common.Address, tracer vm.Tracer) *vm.EVM {
+	rules := cfg.Chain.Rules(height)
+	vmCfg := vm.Config{
+		Tracer: tracer,
+		Debug:  tracer != nil,
+
+		MaxMemorySize:     cfg.Limits.MaxMemorySize,
+		MaxReturnDataSize: cfg.Limits.MaxReturnDataSize,
+		MaxLogCount:       cfg.Limits.MaxLogCount,
+		MaxLogDataSize:    cfg.Limits.MaxLogDataSize,
+		MaxStorageWrites:  cfg.Limits.MaxStorageWrites,
+		MaxSteps:          cfg.Limits.MaxSteps,
+
+		Rules:       &rules,
+		Precompiles: cfg.Chain.PrecompileSet(height),
+	}
+	vctx := vm.Context{
+		CanTransfer:      CanTransfer,
+		Transfer:         Transfer,
+		GetHash:          func(uint64) hash.Hash256 { return hash.Hash256{} },
+		GetAddressByName: loader.AddressByName,
+		Origin:           origin,
+		BlockNumber:      new(big.Int).SetUint64(100),
+		Time:             big.NewInt(time.Now().Unix()),
+		Difficulty:       new(big.Int),
+	}
+	return vm.NewEVM(vctx, statedb, vmCfg)
+}
+
+// runEVM runs the execution on the evm and cancels the evm when the gctx is done
+// The transactions are run with the deadline of the TxTimeout and the view calls are run with the context of the caller
+// The state changes of the cancelled execution are reverted by the evm
+func runEVM(gctx context.Context, evm *vm.EVM, execution func() error) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-gctx.Done():
+			evm.Cancel()
+		case <-done:
+		}
+	}()
+
+	if err := execution(); err != nil {
+		// the execution can fail by itself while the cancellation is racing with it
+		if err == vm.ErrExecutionAborted && evm.Cancelled() {
+			if gctx.Err() == context.DeadlineExceeded {
+				return ErrExecutionTimeout
+			}
+			return gctx.Err()
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package solidity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/core/transaction"
+	"github.com/fletaio/solidity/vm"
+)
+
+// loopCode is the code which never stops
+var loopCode = []byte{byte(vm.JUMPDEST), byte(vm.PUSH1), 0, byte(vm.JUMP)}
+
+// setTestExecutionConfig sets the execution config until the test is finished
+func setTestExecutionConfig(t *testing.T, cfg *ExecutionConfig) {
+	old := execConfig
+	if err := SetExecutionConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		execConfig = old
+	})
+}
+
+func TestDefaultMaxSteps(t *testing.T) {
+	cfg := DefaultExecutionConfig()
+	if cfg.Limits.MaxSteps != DefaultMaxSteps || DefaultMaxSteps == 0 {
+		t.Fatalf("got the default MaxSteps %d, want %d", cfg.Limits.MaxSteps, DefaultMaxSteps)
+	}
+	// the step limit stops the execution before the deadline
+	cfg.TxTimeout = time.Minute
+	setTestExecutionConfig(t, cfg)
+
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ld.addAccount(testUser(2), 0)
+	ctx := data.NewContext(ld)
+	loop, err := createContract(t, ctx, testUser(2), 1, loopCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := callAt(t, ctx, testUser(1), loop, 0, 1); err != vm.ErrStepLimitExceeded {
+		t.Fatalf("got error %v, want %v", err, vm.ErrStepLimitExceeded)
+	}
+}
+
+func TestTxTimeout(t *testing.T) {
+	cfg := DefaultExecutionConfig()
+	cfg.Limits.MaxSteps = 0
+	cfg.TxTimeout = 10 * time.Millisecond
+	setTestExecutionConfig(t, cfg)
+
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ld.addAccount(testUser(2), 0)
+	ctx := data.NewContext(ld)
+	loop, err := createContract(t, ctx, testUser(2), 1, loopCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := callAt(t, ctx, testUser(1), loop, 0, 1); err != ErrExecutionTimeout {
+		t.Fatalf("got error %v, want %v", err, ErrExecutionTimeout)
+	}
+	// the init code is run with the deadline too
+	tx := &CreateContract{
+		Base:   transaction.Base{Type_: createContractType},
+		Seq_:   ctx.Seq(testUser(2)) + 1,
+		From_:  testUser(2),
+		Amount: amount.NewCoinAmount(0, 0),
+		Salt:   2,
+		Code:   loopCode,
+	}
+	if _, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(1, 0)); err != ErrExecutionTimeout {
+		t.Fatalf("got error %v, want %v", err, ErrExecutionTimeout)
+	}
+}
+
+func TestSetExecutionConfig(t *testing.T) {
+	if err := SetExecutionConfig(&ExecutionConfig{}); err != ErrInvalidExecutionConfig {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidExecutionConfig)
+	}
+}
@@ -0,0 +1,268 @@
+package solidity
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/account"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/core/transaction"
+	"github.com/fletaio/solidity/vm"
+)
+
+func init() {
+	data.RegisterAccount("solidity.testAccount", func(t account.Type) account.Account {
+		return &testAccount{
+			Base: account.Base{
+				Type_:    t,
+				Balance_: amount.NewCoinAmount(0, 0),
+			},
+		}
+	}, func(loader data.Loader, a account.Account, signers []common.PublicHash) error {
+		acc := a.(*testAccount)
+		// it only counts the signers of the account like a lenient account type of the chain
+		cnt := 0
+		for _, signer := range signers {
+			for _, k := range acc.KeyHashes {
+				if signer == k {
+					cnt++
+				}
+			}
+		}
+		if cnt < acc.Required {
+			return ErrInvalidSignerCount
+		}
+		return nil
+	})
+}
+
+// testAccount is an account which is signed by the Required number of the KeyHashes
+type testAccount struct {
+	account.Base
+	KeyHashes []common.PublicHash
+	Required  int
+}
+
+// Clone returns the clonend value of it
+func (acc *testAccount) Clone() account.Account {
+	return &testAccount{
+		Base: account.Base{
+			Type_:    acc.Type_,
+			Address_: acc.Address_,
+			Name_:    acc.Name_,
+			Balance_: acc.Balance(),
+		},
+		KeyHashes: append([]common.PublicHash{}, acc.KeyHashes...),
+		Required:  acc.Required,
+	}
+}
+
+// WriteTo is a serialization function
+func (acc *testAccount) WriteTo(w io.Writer) (int64, error) {
+	return acc.Base.WriteTo(w)
+}
+
+// ReadFrom is a deserialization function
+func (acc *testAccount) ReadFrom(r io.Reader) (int64, error) {
+	return acc.Base.ReadFrom(r)
+}
+
+// the types of the tests which are registered by the newTestLoader
+const (
+	contractAccountType = account.Type(1)
+	testAccountType     = account.Type(2)
+	createContractType  = transaction.Type(1)
+	callContractType    = transaction.Type(2)
+	multiCallType       = transaction.Type(3)
+)
+
+// testLoader is the state before the block which has the accounts of the tests only
+type testLoader struct {
+	coord      *common.Coordinate
+	height     uint32
+	accounter  *data.Accounter
+	transactor *data.Transactor
+	eventer    *data.Eventer
+	accounts   map[common.Address]account.Account
+	data       map[string][]byte
+}
+
+func newTestLoader(t testing.TB) *testLoader {
+	t.Helper()
+	coord := common.NewCoordinate(0, 0)
+	ld := &testLoader{
+		coord:      coord,
+		height:     1,
+		accounter:  data.NewAccounter(coord),
+		transactor: data.NewTransactor(coord),
+		eventer:    data.NewEventer(coord),
+		accounts:   map[common.Address]account.Account{},
+		data:       map[string][]byte{},
+	}
+	for name, typ := range map[string]account.Type{
+		"solidity.ContractAccount": contractAccountType,
+		"solidity.testAccount":     testAccountType,
+	} {
+		if err := ld.accounter.RegisterType(name, typ); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+	for name, typ := range map[string]transaction.Type{
+		"solidity.CreateContract": createContractType,
+		"solidity.CallContract":   callContractType,
+		"solidity.MultiCall":      multiCallType,
+	} {
+		if err := ld.transactor.RegisterType(name, typ); err != nil {
+			t.Fatal(name, err)
+		}
+	}
+	if err := ld.eventer.RegisterType("solidity.Log", 1); err != nil {
+		t.Fatal(err)
+	}
+	return ld
+}
+
+func (ld *testLoader) ChainCoord() *common.Coordinate { return ld.coord }
+func (ld *testLoader) Accounter() *data.Accounter     { return ld.accounter }
+func (ld *testLoader) Transactor() *data.Transactor   { return ld.transactor }
+func (ld *testLoader) Eventer() *data.Eventer         { return ld.eventer }
+func (ld *testLoader) TargetHeight() uint32           { return ld.height }
+func (ld *testLoader) LastHash() hash.Hash256         { return hash.Hash256{} }
+func (ld *testLoader) Seq(addr common.Address) uint64 { return 0 }
+
+func (ld *testLoader) Account(addr common.Address) (account.Account, error) {
+	if acc, has := ld.accounts[addr]; has {
+		return acc, nil
+	}
+	return nil, data.ErrNotExistAccount
+}
+
+func (ld *testLoader) AddressByName(name string) (common.Address, error) {
+	for addr, acc := range ld.accounts {
+		if len(name) > 0 && acc.Name() == name {
+			return addr, nil
+		}
+	}
+	return common.Address{}, data.ErrNotExistAccountName
+}
+
+func (ld *testLoader) IsExistAccount(addr common.Address) (bool, error) {
+	_, has := ld.accounts[addr]
+	return has, nil
+}
+
+func (ld *testLoader) IsExistAccountName(name string) (bool, error) {
+	_, err := ld.AddressByName(name)
+	return err == nil, nil
+}
+
+func (ld *testLoader) AccountData(addr common.Address, name []byte) []byte {
+	return ld.data[string(addr[:])+string(name)]
+}
+
+func (ld *testLoader) setAccountData(addr common.Address, name []byte, value []byte) {
+	ld.data[string(addr[:])+string(name)] = value
+}
+
+// addAccount adds the test account of the address which has the coins and the key hashes
+func (ld *testLoader) addAccount(addr common.Address, coins uint64, keys ...common.PublicHash) *testAccount {
+	acc := &testAccount{
+		Base: account.Base{
+			Type_:    testAccountType,
+			Address_: addr,
+			Balance_: amount.NewCoinAmount(coins, 0),
+		},
+		KeyHashes: keys,
+		Required:  1,
+	}
+	ld.accounts[addr] = acc
+	return acc
+}
+
+// addLegacyContract adds the contract account which is created before the shared code store
+// The address of the legacy contract is the address of the coordinate of the creation
+func (ld *testLoader) addLegacyContract(coord *common.Coordinate, name string, code []byte) common.Address {
+	addr := common.NewAddress(coord, 0)
+	ld.accounts[addr] = &ContractAccount{
+		Base: account.Base{
+			Type_:    contractAccountType,
+			Address_: addr,
+			Name_:    name,
+			Balance_: amount.NewCoinAmount(0, 0),
+		},
+	}
+	h := hash.Hash(code)
+	bs := make([]byte, 4)
+	binary.LittleEndian.PutUint32(bs, uint32(len(code)))
+	ld.setAccountData(addr, KeywordCode[:], code)
+	ld.setAccountData(addr, KeywordCodeHash[:], h[:])
+	ld.setAccountData(addr, KeywordCodeSize[:], bs)
+	return addr
+}
+
+// newTx returns the transaction of the type name
+func (ld *testLoader) newTx(t testing.TB, name string) transaction.Transaction {
+	t.Helper()
+	tx, err := ld.transactor.NewByTypeName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// testUser returns the address of the n-th account of the tests
+func testUser(n uint64) common.Address {
+	return common.NewAddress(common.NewCoordinate(1, 0), n)
+}
+
+// testKey returns the n-th key hash of the tests
+func testKey(n byte) common.PublicHash {
+	var k common.PublicHash
+	k[0] = n
+	return k
+}
+
+// initCode returns the init code which deploys the runtime code
+func initCode(runtime []byte) []byte {
+	code := []byte{
+		byte(vm.PUSH2), byte(len(runtime) >> 8), byte(len(runtime)),
+		byte(vm.DUP1),
+		byte(vm.PUSH1), 12, // the offset of the runtime code
+		byte(vm.PUSH1), 0,
+		byte(vm.CODECOPY),
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}
+	return append(code, runtime...)
+}
+
+// balanceOf returns the balance of the account of the address in the context
+func balanceOf(t testing.TB, ctx *data.Context, addr common.Address) *amount.Amount {
+	t.Helper()
+	acc, err := ctx.Account(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acc.Balance()
+}
+
+// createContract executes the CreateContract of the runtime code by the from and returns the address of the contract
+func createContract(t testing.TB, ctx *data.Context, from common.Address, salt uint64, runtime []byte) (common.Address, error) {
+	t.Helper()
+	tx := &CreateContract{
+		Base:   transaction.Base{Type_: createContractType},
+		Seq_:   ctx.Seq(from) + 1,
+		From_:  from,
+		Amount: amount.NewCoinAmount(0, 0),
+		Salt:   salt,
+		Code:   initCode(runtime),
+	}
+	if _, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(ctx.TargetHeight(), 0)); err != nil {
+		return common.Address{}, err
+	}
+	return ContractAddress(from, salt), nil
+}
@@ -0,0 +1,186 @@
+package solidity
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+)
+
+// RentPolicy is the storage rent policy of the chain
+// The contract pays RentPerByte for each byte of its storage slots in each epoch
+type RentPolicy struct {
+	RentPerByte *amount.Amount
+	EpochLength uint32
+}
+
+var rentPolicy *RentPolicy
+
+// SetRentPolicy is used for enabling the storage rent of the chain, nil disables it
+func SetRentPolicy(p *RentPolicy) {
+	rentPolicy = p
+}
+
+// rentOf returns the rent of the storage size from the height to the target height
+func (p *RentPolicy) rentOf(size uint64, height uint32, target uint32) (*amount.Amount, error) {
+	if p.EpochLength == 0 || target <= height {
+		return amount.NewCoinAmount(0, 0), nil
+	}
+	epochs := uint64(target/p.EpochLength) - uint64(height/p.EpochLength)
+	if size > math.MaxInt64 {
+		return nil, ErrRentOverflow
+	}
+	// the amount is not limited, so the size and the epochs are multiplied separately to avoid the overflow of the int64
+	return p.RentPerByte.MulC(int64(size)).MulC(int64(epochs)), nil
+}
+
+// rentContractCountKey is the key of the number of the contracts of the rent registry in the ContractRegistryAddress
+var rentContractCountKey = hash.Hash([]byte("__RENTCONTRACTCOUNT__"))
+
+// rentContractKey returns the key of the i-th contract of the rent registry in the ContractRegistryAddress
+func rentContractKey(i uint64) []byte {
+	bs := make([]byte, 8)
+	binary.BigEndian.PutUint64(bs, i)
+	k := hash.Hash(append([]byte("__RENTCONTRACT__"), bs...))
+	return k[:]
+}
+
+// rentContractPosKey returns the key of the position of the contract in the rent registry in the ContractRegistryAddress
+func rentContractPosKey(addr common.Address) []byte {
+	k := hash.Hash(append([]byte("__RENTCONTRACTPOS__"), addr[:]...))
+	return k[:]
+}
+
+// rentHeight returns the height until which the rent of the address is paid
+// The contract which is created before the rent doesn't have the height, so its rent starts from the target height
+func rentHeight(loader data.Loader, addr common.Address, target uint32) uint32 {
+	bs := loader.AccountData(addr, KeywordRentHeight[:])
+	if len(bs) != 4 {
+		return target
+	}
+	return binary.LittleEndian.Uint32(bs)
+}
+
+// setRentHeight updates the height until which the rent of the address is paid
+func setRentHeight(ctx *data.Context, addr common.Address, height uint32) {
+	bs := make([]byte, 4)
+	binary.LittleEndian.PutUint32(bs, height)
+	ctx.SetAccountData(addr, KeywordRentHeight[:], bs)
+}
+
+// rentContractCount returns the number of the contracts in the rent registry
+func rentContractCount(ctx *data.Context) uint64 {
+	return readUint64(ctx, ContractRegistryAddress, rentContractCountKey)
+}
+
+// setRentContractAt puts the contract of the address to the i-th position of the rent registry
+func setRentContractAt(ctx *data.Context, i uint64, addr common.Address) {
+	ctx.SetAccountData(ContractRegistryAddress, rentContractKey(i), addr[:])
+	bs := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bs, i+1)
+	ctx.SetAccountData(ContractRegistryAddress, rentContractPosKey(addr), bs)
+}
+
+// registerRentContract adds the contract of the address to the rent registry which is used by the ChargeRent
+// It does nothing when the contract is already in the registry
+func registerRentContract(ctx *data.Context, addr common.Address) {
+	if len(ctx.AccountData(ContractRegistryAddress, rentContractPosKey(addr))) > 0 {
+		return
+	}
+	cnt := rentContractCount(ctx)
+	setRentContractAt(ctx, cnt, addr)
+	bs := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bs, cnt+1)
+	ctx.SetAccountData(ContractRegistryAddress, rentContractCountKey[:], bs)
+}
+
+// unregisterRentContract removes the contract of the address from the rent registry
+// The last contract is moved to the position of the removed one, so the registry has no hole
+func unregisterRentContract(ctx *data.Context, addr common.Address) {
+	bs := ctx.AccountData(ContractRegistryAddress, rentContractPosKey(addr))
+	if len(bs) != 8 {
+		return
+	}
+	pos := binary.LittleEndian.Uint64(bs) - 1
+	last := rentContractCount(ctx) - 1
+	if pos != last {
+		var moved common.Address
+		copy(moved[:], ctx.AccountData(ContractRegistryAddress, rentContractKey(last)))
+		setRentContractAt(ctx, pos, moved)
+	}
+	ctx.SetAccountData(ContractRegistryAddress, rentContractKey(last), nil)
+	ctx.SetAccountData(ContractRegistryAddress, rentContractPosKey(addr), nil)
+	if last == 0 {
+		ctx.SetAccountData(ContractRegistryAddress, rentContractCountKey[:], nil)
+	} else {
+		cnt := make([]byte, 8)
+		binary.LittleEndian.PutUint64(cnt, last)
+		ctx.SetAccountData(ContractRegistryAddress, rentContractCountKey[:], cnt)
+	}
+}
+
+// payRent charges the rent of the contract of the address until the height
+// The legacy contracts which are created before the slot accounting are exempt from the rent,
+// because the size of their storage is not known
+// The contract which pays the rent is registered to the rent registry again when it is revived
+func payRent(ctx *data.Context, addr common.Address, height uint32) error {
+	acc, err := ctx.Account(addr)
+	if err != nil {
+		return err
+	}
+	if _, is := acc.(*ContractAccount); !is {
+		return nil
+	}
+	sd := &StateDB{Context: ctx}
+	size, accounted := sd.GetSlotSize(addr)
+	if !accounted {
+		return nil
+	}
+	rent, err := rentPolicy.rentOf(size, rentHeight(ctx, addr, height), height)
+	if err != nil {
+		return err
+	}
+	if acc.Balance().Less(rent) {
+		return ErrDormantContract
+	}
+	if err := acc.SubBalance(rent); err != nil {
+		return err
+	}
+	setRentHeight(ctx, addr, height)
+	registerRentContract(ctx, addr)
+	return nil
+}
+
+// ChargeRent charges the rent of all the contracts in the rent registry until the height
+// It is a hook for the node: this package doesn't process the blocks, so the node should call it with the context of the block
+// after the transactions of the block are executed when the height is an epoch boundary (height % EpochLength == 0),
+// so the contracts which are not called pay the rent of their storage too
+// The contracts which cannot pay the rent become dormant and they are removed from the registry until they are revived,
+// so the registry has only the live contracts
+func ChargeRent(ctx *data.Context, height uint32) error {
+	if rentPolicy == nil || rentPolicy.EpochLength == 0 || height%rentPolicy.EpochLength != 0 {
+		return nil
+	}
+	for i := uint64(0); i < rentContractCount(ctx); {
+		var addr common.Address
+		copy(addr[:], ctx.AccountData(ContractRegistryAddress, rentContractKey(i)))
+		if is, err := ctx.IsExistAccount(addr); err != nil {
+			return err
+		} else if !is {
+			// the last contract is moved to the i-th position
+			unregisterRentContract(ctx, addr)
+			continue
+		}
+		if err := payRent(ctx, addr, height); err == ErrDormantContract {
+			unregisterRentContract(ctx, addr)
+			continue
+		} else if err != nil {
+			return err
+		}
+		i++
+	}
+	return nil
+}
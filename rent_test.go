@@ -0,0 +1,168 @@
+package solidity
+
+import (
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/core/transaction"
+	"github.com/fletaio/solidity/vm"
+)
+
+// destroyCode destroys the contract and sends the balance to the caller
+var destroyCode = []byte{byte(vm.CALLER), byte(vm.SELFDESTRUCT)}
+
+// setTestRentPolicy sets the rent policy of 1 per byte of each 10 blocks until the test is finished
+func setTestRentPolicy(t *testing.T) {
+	SetRentPolicy(&RentPolicy{
+		RentPerByte: amount.NewCoinAmount(0, 1),
+		EpochLength: 10,
+	})
+	t.Cleanup(func() {
+		SetRentPolicy(nil)
+	})
+}
+
+// callAt executes the CallContract of the value to the contract at the height
+func callAt(t *testing.T, ctx *data.Context, from common.Address, to common.Address, value uint64, height uint32) error {
+	t.Helper()
+	tx := &CallContract{
+		Base:   transaction.Base{Type_: callContractType},
+		Seq_:   ctx.Seq(from) + 1,
+		From_:  from,
+		Amount: amount.NewCoinAmount(0, value),
+		To:     to,
+	}
+	_, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(height, 0))
+	return err
+}
+
+// rentState returns the context which has the store contract of a storage slot of 64 bytes at the height 1
+func rentState(t *testing.T) (*data.Context, *testLoader, common.Address) {
+	setTestRentPolicy(t)
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ld.addAccount(testUser(2), 0)
+	ctx := data.NewContext(ld)
+	store, err := createContract(t, ctx, testUser(2), 1, storeCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := callAt(t, ctx, testUser(1), store, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	return ctx, ld, store
+}
+
+func TestRentRevive(t *testing.T) {
+	ctx, _, store := rentState(t)
+
+	// the rent of 2 epochs is 128
+	if err := callAt(t, ctx, testUser(1), store, 0, 25); err != ErrDormantContract {
+		t.Fatalf("got error %v, want %v", err, ErrDormantContract)
+	}
+	if err := callAt(t, ctx, testUser(1), store, 100, 25); err != ErrDormantContract {
+		t.Fatalf("got error %v, want %v", err, ErrDormantContract)
+	}
+	// the value of the failed call is not transferred
+	if b := balanceOf(t, ctx, testUser(1)); !b.Equal(amount.NewCoinAmount(10, 0)) {
+		t.Fatalf("got the caller balance %v, want 10 coins", b)
+	}
+
+	// the value of the call revives the dormant contract
+	if err := callAt(t, ctx, testUser(1), store, 200, 25); err != nil {
+		t.Fatal(err)
+	}
+	if b := balanceOf(t, ctx, store); !b.Equal(amount.NewCoinAmount(0, 72)) {
+		t.Fatalf("got the contract balance %v, want 72", b)
+	}
+	if h := rentHeight(ctx, store, 0); h != 25 {
+		t.Fatalf("got the rent height %d, want 25", h)
+	}
+}
+
+func TestChargeRent(t *testing.T) {
+	ctx, _, store := rentState(t)
+	if err := callAt(t, ctx, testUser(1), store, 200, 1); err != nil {
+		t.Fatal(err)
+	}
+	if cnt := rentContractCount(ctx); cnt != 1 {
+		t.Fatalf("got %d contracts in the rent registry, want 1", cnt)
+	}
+
+	// it is charged only at the epoch boundary
+	if err := ChargeRent(ctx, 15); err != nil {
+		t.Fatal(err)
+	}
+	if err := ChargeRent(ctx, 20); err != nil {
+		t.Fatal(err)
+	}
+	if b := balanceOf(t, ctx, store); !b.Equal(amount.NewCoinAmount(0, 72)) {
+		t.Fatalf("got the contract balance %v, want 72", b)
+	}
+
+	// the dormant contract is removed from the registry until it is revived
+	if err := ChargeRent(ctx, 30); err != nil {
+		t.Fatal(err)
+	}
+	if err := ChargeRent(ctx, 40); err != nil {
+		t.Fatal(err)
+	}
+	if cnt := rentContractCount(ctx); cnt != 0 {
+		t.Fatalf("got %d contracts in the rent registry, want 0", cnt)
+	}
+	if err := callAt(t, ctx, testUser(1), store, 100, 40); err != nil {
+		t.Fatal(err)
+	}
+	if cnt := rentContractCount(ctx); cnt != 1 {
+		t.Fatalf("got %d contracts in the rent registry, want 1", cnt)
+	}
+}
+
+func TestRentRegistryPrune(t *testing.T) {
+	ctx, _, store := rentState(t)
+	destroyer, err := createContract(t, ctx, testUser(2), 2, destroyCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cnt := rentContractCount(ctx); cnt != 2 {
+		t.Fatalf("got %d contracts in the rent registry, want 2", cnt)
+	}
+	if err := callAt(t, ctx, testUser(1), store, 100, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := callAt(t, ctx, testUser(1), destroyer, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	// the destroyed contract is removed and the store contract is moved to its position
+	if cnt := rentContractCount(ctx); cnt != 1 {
+		t.Fatalf("got %d contracts in the rent registry, want 1", cnt)
+	}
+	if err := ChargeRent(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	if h := rentHeight(ctx, store, 0); h != 10 {
+		t.Fatalf("got the rent height %d of the store contract, want 10", h)
+	}
+	if b := balanceOf(t, ctx, store); !b.Equal(amount.NewCoinAmount(0, 36)) {
+		t.Fatalf("got the contract balance %v, want 36", b)
+	}
+}
+
+func TestRentLegacyExempt(t *testing.T) {
+	setTestRentPolicy(t)
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	legacy := ld.addLegacyContract(common.NewCoordinate(5, 0), "", storeCode)
+	ctx := data.NewContext(ld)
+	if err := callAt(t, ctx, testUser(1), legacy, 0, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&ViewDB{Loader: ld}).PayRent(legacy); err != nil {
+		t.Fatal(err)
+	}
+	if cnt := rentContractCount(ctx); cnt != 0 {
+		t.Fatalf("the legacy contract is registered to the rent registry")
+	}
+}
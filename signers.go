@@ -0,0 +1,74 @@
+package solidity
+
+import (
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/account"
+	"github.com/fletaio/core/data"
+)
+
+// AccountKeysFunc returns the key hashes which are registered to the account
+type AccountKeysFunc func(acc account.Account) []common.PublicHash
+
+var accountKeysMap = map[string]AccountKeysFunc{}
+
+// RegisterAccountKeys is used for registering the key set of the account type of the name
+// The key set is defined by the account type (the key hash of a single account and the key hashes of a multisig account),
+// so the node registers the key sets of its account types
+func RegisterAccountKeys(name string, fn AccountKeysFunc) {
+	accountKeysMap[name] = fn
+}
+
+// accountKeys returns the key set of the account by the type of it which is registered to the accounter
+// It returns false when the key set of the account type is not registered
+func accountKeys(loader data.Loader, acc account.Account) ([]common.PublicHash, bool) {
+	for name, fn := range accountKeysMap {
+		if t, err := loader.Accounter().TypeByName(name); err == nil && t == acc.Type() {
+			return fn(acc), true
+		}
+	}
+	return nil, false
+}
+
+// validateSigners checks the signers of the contract transaction of the account
+// The signers should be unique keys of the key set of the account and they are validated by the accounter of the account,
+// so a multisig account is signed by the keys which satisfy its own rule
+// The keys are checked by the accounter only when the key set of the account type is not registered
+func validateSigners(loader data.Loader, acc account.Account, signers []common.PublicHash) error {
+	if len(signers) == 0 {
+		return ErrInvalidSignerCount
+	}
+	signerMap := map[common.PublicHash]bool{}
+	for _, signer := range signers {
+		if signerMap[signer] {
+			return ErrDuplicatedSigner
+		}
+		signerMap[signer] = true
+	}
+	if keys, has := accountKeys(loader, acc); has {
+		keyMap := map[common.PublicHash]bool{}
+		for _, k := range keys {
+			keyMap[k] = true
+		}
+		for _, signer := range signers {
+			if !keyMap[signer] {
+				return ErrInvalidSigner
+			}
+		}
+	}
+	return loader.Accounter().Validate(loader, acc, signers)
+}
+
+// isAllowedAccount returns true when the key set of the account has the allowed key to create the contract
+// The account of which the key set is not registered is not allowed
+func isAllowedAccount(loader data.Loader, acc account.Account) bool {
+	keys, has := accountKeys(loader, acc)
+	if !has {
+		return false
+	}
+	for _, k := range keys {
+		if allowedKeyMap[k] {
+			return true
+		}
+	}
+	return false
+}
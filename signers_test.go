@@ -0,0 +1,72 @@
+package solidity
+
+import (
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/account"
+)
+
+// registerTestAccountKeys registers the key set of the testAccount until the test is finished
+func registerTestAccountKeys(t *testing.T) {
+	RegisterAccountKeys("solidity.testAccount", func(acc account.Account) []common.PublicHash {
+		return acc.(*testAccount).KeyHashes
+	})
+	t.Cleanup(func() {
+		delete(accountKeysMap, "solidity.testAccount")
+	})
+}
+
+func TestValidateSigners(t *testing.T) {
+	registerTestAccountKeys(t)
+	ld := newTestLoader(t)
+	acc := ld.addAccount(testUser(1), 0, testKey(1), testKey(2))
+	acc.Required = 2
+
+	tests := []struct {
+		name    string
+		signers []common.PublicHash
+		err     error
+	}{
+		{"empty", nil, ErrInvalidSignerCount},
+		{"duplicated", []common.PublicHash{testKey(1), testKey(1)}, ErrDuplicatedSigner},
+		{"foreign", []common.PublicHash{testKey(1), testKey(2), testKey(3)}, ErrInvalidSigner},
+		{"not enough", []common.PublicHash{testKey(2)}, ErrInvalidSignerCount},
+		{"multisig", []common.PublicHash{testKey(2), testKey(1)}, nil},
+	}
+	for _, tt := range tests {
+		if err := validateSigners(ld, acc, tt.signers); err != tt.err {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestCreateContractAllowedAccount(t *testing.T) {
+	ld := newTestLoader(t)
+	// the allowed key is a key of the multisig account which doesn't sign the transaction
+	ld.addAccount(testUser(1), 10, testKey(1), testKey(2))
+	tx := ld.newTx(t, "solidity.CreateContract").(*CreateContract)
+	tx.Seq_ = 1
+	tx.From_ = testUser(1)
+	signers := []common.PublicHash{testKey(1)}
+
+	RegisterAllowedKey(testKey(2))
+	defer UnregisterAllowedKey(testKey(2))
+	// the key set of the account is unknown
+	if err := ld.transactor.Validate(ld, tx, signers); err != ErrNotAllowed {
+		t.Fatalf("got error %v, want %v", err, ErrNotAllowed)
+	}
+
+	registerTestAccountKeys(t)
+	if err := ld.transactor.Validate(ld, tx, signers); err != nil {
+		t.Fatal(err)
+	}
+	if err := ld.transactor.Validate(ld, tx, []common.PublicHash{testKey(1), testKey(3)}); err != ErrInvalidSigner {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidSigner)
+	}
+
+	UnregisterAllowedKey(testKey(2))
+	if err := ld.transactor.Validate(ld, tx, signers); err != ErrNotAllowed {
+		t.Fatalf("got error %v, want %v", err, ErrNotAllowed)
+	}
+}
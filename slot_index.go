@@ -0,0 +1,76 @@
+package solidity
+
+import (
+	"encoding/binary"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/solidity/vm"
+)
+
+// SlotIndexAddress is the address that holds the index of the storage slots of the contracts
+// The index is not in the account of the contract, so the contract cannot overwrite it by its storage
+// It is in the reserved coordinate, so it cannot be an address of an account
+var SlotIndexAddress = vm.ReservedAddress(0x534C4F54494E4458) // "SLOTINDX"
+
+// slotKeyKey returns the key of the i-th storage key of the address in the SlotIndexAddress
+func slotKeyKey(addr common.Address, i uint64) []byte {
+	bs := make([]byte, common.AddressSize+8)
+	copy(bs, addr[:])
+	binary.BigEndian.PutUint64(bs[common.AddressSize:], i)
+	k := hash.Hash(append([]byte("__SLOTKEY__"), bs...))
+	return k[:]
+}
+
+// slotPosKey returns the key of the position of the storage key of the address in the SlotIndexAddress
+func slotPosKey(addr common.Address, h hash.Hash256) []byte {
+	k := hash.Hash(append(append([]byte("__SLOTPOS__"), addr[:]...), h[:]...))
+	return k[:]
+}
+
+// slotKeyAt returns the i-th storage key of the address
+func slotKeyAt(ctx *data.Context, addr common.Address, i uint64) hash.Hash256 {
+	var h hash.Hash256
+	copy(h[:], ctx.AccountData(SlotIndexAddress, slotKeyKey(addr, i)))
+	return h
+}
+
+// setSlotKeyAt puts the storage key to the i-th position of the index of the address
+func setSlotKeyAt(ctx *data.Context, addr common.Address, i uint64, h hash.Hash256) {
+	ctx.SetAccountData(SlotIndexAddress, slotKeyKey(addr, i), h[:])
+	bs := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bs, i+1)
+	ctx.SetAccountData(SlotIndexAddress, slotPosKey(addr, h), bs)
+}
+
+// addSlotKey appends the storage key to the index of the address which has the cnt storage keys
+func addSlotKey(ctx *data.Context, addr common.Address, cnt uint64, h hash.Hash256) {
+	setSlotKeyAt(ctx, addr, cnt, h)
+}
+
+// removeSlotKey removes the storage key from the index of the address which has the cnt storage keys
+// The last storage key is moved to the position of the removed one, so the index has no hole
+func removeSlotKey(ctx *data.Context, addr common.Address, cnt uint64, h hash.Hash256) {
+	bs := ctx.AccountData(SlotIndexAddress, slotPosKey(addr, h))
+	if len(bs) != 8 || cnt == 0 {
+		return
+	}
+	pos := binary.LittleEndian.Uint64(bs) - 1
+	last := cnt - 1
+	if pos != last {
+		setSlotKeyAt(ctx, addr, pos, slotKeyAt(ctx, addr, last))
+	}
+	ctx.SetAccountData(SlotIndexAddress, slotKeyKey(addr, last), nil)
+	ctx.SetAccountData(SlotIndexAddress, slotPosKey(addr, h), nil)
+}
+
+// clearSlots deletes the storage slots of the address which has the cnt storage keys and the index of them
+func clearSlots(ctx *data.Context, addr common.Address, cnt uint64) {
+	for i := uint64(0); i < cnt; i++ {
+		h := slotKeyAt(ctx, addr, i)
+		ctx.SetAccountData(addr, h[:], nil)
+		ctx.SetAccountData(SlotIndexAddress, slotKeyKey(addr, i), nil)
+		ctx.SetAccountData(SlotIndexAddress, slotPosKey(addr, h), nil)
+	}
+}
@@ -12,28 +12,46 @@ import (
 
 // keywords StateDB
 var (
-	KeywordCode     = hash.Hash([]byte("__CODE__"))
-	KeywordCodeHash = hash.Hash([]byte("__CODEHASH__"))
-	KeywordCodeSize = hash.Hash([]byte("__CODESIZE__"))
-	KeywordSuicide  = hash.Hash([]byte("__SUICIDE__"))
-	KeywordMap      = map[hash.Hash256]bool{}
+	KeywordCode       = hash.Hash([]byte("__CODE__"))
+	KeywordCodeHash   = hash.Hash([]byte("__CODEHASH__"))
+	KeywordCodeSize   = hash.Hash([]byte("__CODESIZE__"))
+	KeywordSuicide    = hash.Hash([]byte("__SUICIDE__"))
+	KeywordSlotCount  = hash.Hash([]byte("__SLOTCOUNT__"))
+	KeywordSlotSize   = hash.Hash([]byte("__SLOTSIZE__"))
+	KeywordAccounted  = hash.Hash([]byte("__ACCOUNTED__"))
+	KeywordRentHeight = hash.Hash([]byte("__RENTHEIGHT__"))
+	KeywordMap        = map[hash.Hash256]bool{}
 )
 
 func init() {
 	KeywordMap[KeywordCode] = true
 	KeywordMap[KeywordCodeHash] = true
+	KeywordMap[KeywordCodeSize] = true
 	KeywordMap[KeywordSuicide] = true
+	KeywordMap[KeywordSlotCount] = true
+	KeywordMap[KeywordSlotSize] = true
+	KeywordMap[KeywordAccounted] = true
+	KeywordMap[KeywordRentHeight] = true
+}
+
+type accountDataLoader interface {
+	AccountData(addr common.Address, name []byte) []byte
 }
 
 // StateDB is an EVM database for full state querying.
 type StateDB struct {
-	Context *data.Context
-	Coord   *common.Coordinate
+	Context  *data.Context
+	Coord    *common.Coordinate
+	suicides []common.Address
 }
 
 // CreateAccount creates the sub account of the address to the context inside of EVM
+// It panics when the address is destroyed because the storage of the destroyed contract remains
 func (sd *StateDB) CreateAccount(addr common.Address, name string) {
 	//log.Println("CreateAccount", addr)
+	if IsDestroyedAddress(sd.Context, addr) {
+		panic(ErrDestroyedAddress)
+	}
 	a, err := sd.Context.Accounter().NewByTypeName("solidity.ContractAccount")
 	if err != nil {
 		panic(err)
@@ -44,6 +62,9 @@ func (sd *StateDB) CreateAccount(addr common.Address, name string) {
 	if err := sd.Context.CreateAccount(acc); err != nil {
 		panic(err)
 	}
+	sd.Context.SetAccountData(addr, KeywordAccounted[:], []byte{1})
+	setRentHeight(sd.Context, addr, sd.Coord.Height)
+	registerRentContract(sd.Context, addr)
 }
 
 // SubBalance reduce the balance from the account of the address
@@ -96,23 +117,54 @@ func (sd *StateDB) GetCodeHash(addr common.Address) hash.Hash256 {
 	return sd.GetState(addr, KeywordCodeHash)
 }
 
-// GetCode returns the code of the address
+// GetCode returns the code of the address from the shared code store
+// The code of the legacy contract is migrated to the shared code store when it is loaded first,
+// so the nested callees are migrated as well as the called contracts of the transactions
 func (sd *StateDB) GetCode(addr common.Address) []byte {
 	//log.Println("GetCode", addr)
-	return sd.Context.AccountData(addr, KeywordCode[:])
+	sd.MigrateCode(addr)
+	return loadCode(sd.Context, addr)
 }
 
 // SetCode updates the code to the address
+// The code is stored to the shared code store and the account holds only the code hash
 func (sd *StateDB) SetCode(addr common.Address, code []byte) {
 	//log.Println("SetCode", addr, code)
-	sd.Context.SetAccountData(addr, KeywordCode[:], code)
+	sd.releaseCode(addr)
 	h := hash.Hash(code)
+	retainCode(sd.Context, h, code)
 	sd.Context.SetAccountData(addr, KeywordCodeHash[:], h[:])
 	bs := make([]byte, 4)
 	binary.LittleEndian.PutUint32(bs, uint32(len(code)))
 	sd.Context.SetAccountData(addr, KeywordCodeSize[:], bs)
 }
 
+// MigrateCode moves the code of the account which is stored before the shared code store
+// It returns false when the account doesn't have the code to migrate
+// The GetCode migrates the code before loading it, so the legacy contracts are migrated lazily when they are used
+func (sd *StateDB) MigrateCode(addr common.Address) bool {
+	code := sd.Context.AccountData(addr, KeywordCode[:])
+	if len(code) == 0 {
+		return false
+	}
+	h := hash.Hash(code)
+	retainCode(sd.Context, h, code)
+	sd.Context.SetAccountData(addr, KeywordCode[:], nil)
+	sd.Context.SetAccountData(addr, KeywordCodeHash[:], h[:])
+	return true
+}
+
+// releaseCode drops the reference of the account to its code in the shared code store
+func (sd *StateDB) releaseCode(addr common.Address) {
+	if len(sd.Context.AccountData(addr, KeywordCode[:])) > 0 {
+		sd.Context.SetAccountData(addr, KeywordCode[:], nil)
+		return
+	}
+	if h := sd.GetCodeHash(addr); h != (hash.Hash256{}) {
+		releaseCode(sd.Context, h)
+	}
+}
+
 // GetCodeSize returns the code size of the address
 func (sd *StateDB) GetCodeSize(addr common.Address) int {
 	//log.Println("GetCodeSize", addr)
@@ -136,18 +188,80 @@ func (sd *StateDB) GetState(addr common.Address, h hash.Hash256) hash.Hash256 {
 }
 
 // SetState updates value by the hash of the address
+// The zero value deletes the slot and the slot count and size of the address are updated
+//
+// The slots are counted and indexed only for the accounts which are created with the accounting marker.
+// The accounts which are created before the accounting (legacy accounts) have the slots which are not counted,
+// so their counters are neither incremented nor decremented and they stay zero
 func (sd *StateDB) SetState(addr common.Address, h hash.Hash256, v hash.Hash256) {
 	//log.Println("SetState", addr, h, v)
 	if KeywordMap[h] {
 		panic("reserved keyword")
 	}
+	has := len(sd.Context.AccountData(addr, h[:])) > 0
+	if v == (hash.Hash256{}) {
+		if has {
+			sd.Context.SetAccountData(addr, h[:], nil)
+			if isAccounted(sd.Context, addr) {
+				cnt := readUint64(sd.Context, addr, KeywordSlotCount)
+				removeSlotKey(sd.Context, addr, cnt, h)
+				sd.setUint64(addr, KeywordSlotCount, cnt-1)
+				sd.setUint64(addr, KeywordSlotSize, readUint64(sd.Context, addr, KeywordSlotSize)-uint64(len(h)+len(v)))
+			}
+		}
+		return
+	}
 	sd.Context.SetAccountData(addr, h[:], v[:])
+	if !has && isAccounted(sd.Context, addr) {
+		cnt := readUint64(sd.Context, addr, KeywordSlotCount)
+		addSlotKey(sd.Context, addr, cnt, h)
+		sd.setUint64(addr, KeywordSlotCount, cnt+1)
+		sd.setUint64(addr, KeywordSlotSize, readUint64(sd.Context, addr, KeywordSlotSize)+uint64(len(h)+len(v)))
+	}
+}
+
+// GetSlotCount returns the number of the storage slots of the address
+// It returns false for the legacy accounts which don't have the accounting marker, because their slots are not counted
+func (sd *StateDB) GetSlotCount(addr common.Address) (uint64, bool) {
+	return readUint64(sd.Context, addr, KeywordSlotCount), isAccounted(sd.Context, addr)
+}
+
+// GetSlotSize returns the byte size of the storage slots of the address
+// It returns false for the legacy accounts which don't have the accounting marker, because their slots are not counted
+func (sd *StateDB) GetSlotSize(addr common.Address) (uint64, bool) {
+	return readUint64(sd.Context, addr, KeywordSlotSize), isAccounted(sd.Context, addr)
+}
+
+// isAccounted returns true when the slots of the address are counted from the creation of the account
+func isAccounted(loader accountDataLoader, addr common.Address) bool {
+	bs := loader.AccountData(addr, KeywordAccounted[:])
+	return len(bs) > 0 && bs[0] == 1
+}
+
+// readUint64 returns the uint64 value of the key of the address or zero when it is not stored
+func readUint64(loader accountDataLoader, addr common.Address, key hash.Hash256) uint64 {
+	bs := loader.AccountData(addr, key[:])
+	if len(bs) == 8 {
+		return binary.LittleEndian.Uint64(bs)
+	}
+	return 0
+}
+
+func (sd *StateDB) setUint64(addr common.Address, key hash.Hash256, v uint64) {
+	if v == 0 {
+		sd.Context.SetAccountData(addr, key[:], nil)
+		return
+	}
+	bs := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bs, v)
+	sd.Context.SetAccountData(addr, key[:], bs)
 }
 
 // Suicide make the address to dead state
 func (sd *StateDB) Suicide(addr common.Address) bool {
 	//log.Println("Suicide", addr)
 	sd.Context.SetAccountData(addr, KeywordSuicide[:], []byte{1})
+	sd.suicides = append(sd.suicides, addr)
 	return true
 }
 
@@ -158,6 +272,17 @@ func (sd *StateDB) HasSuicided(addr common.Address) bool {
 	return len(bs) > 0 && bs[0] == 1
 }
 
+// PayRent charges the storage rent of the contract of the address until the current height
+// The contract is dormant while it cannot pay the rent and it is revived by funding the rent of the dormant period,
+// the value of the call is credited before the rent, so the call can revive the contract by its value
+func (sd *StateDB) PayRent(addr common.Address) error {
+	//log.Println("PayRent", addr)
+	if rentPolicy == nil {
+		return nil
+	}
+	return payRent(sd.Context, addr, sd.Coord.Height)
+}
+
 // Exist checks that the account of the address is exist or not
 func (sd *StateDB) Exist(addr common.Address) bool {
 	//log.Println("Exist", addr)
@@ -211,3 +336,49 @@ func (sd *StateDB) AddLog(l *vm.Log) {
 	ev.Removed = l.Removed
 	sd.Context.EmitEvent(e)
 }
+
+// Finalize removes the suicided accounts at the end of the transaction
+// It zeroes the balance, deletes the code and the storage slots and removes the account
+// The storage slots of the legacy accounts are not indexed, so they are left and the address is tombstoned to forbid the reuse of it
+func (sd *StateDB) Finalize() error {
+	removed := map[common.Address]bool{}
+	for _, addr := range sd.suicides {
+		if removed[addr] {
+			continue
+		}
+		// the suicide can be reverted by the snapshot after it is called
+		if !sd.HasSuicided(addr) {
+			continue
+		}
+		removed[addr] = true
+
+		acc, err := sd.Context.Account(addr)
+		if err != nil {
+			return err
+		}
+		if err := acc.SubBalance(acc.Balance().Clone()); err != nil {
+			return err
+		}
+		sd.releaseCode(addr)
+		accounted := isAccounted(sd.Context, addr)
+		if accounted {
+			clearSlots(sd.Context, addr, readUint64(sd.Context, addr, KeywordSlotCount))
+		}
+		sd.Context.SetAccountData(addr, KeywordCodeHash[:], nil)
+		sd.Context.SetAccountData(addr, KeywordCodeSize[:], nil)
+		sd.Context.SetAccountData(addr, KeywordSuicide[:], nil)
+		sd.Context.SetAccountData(addr, KeywordSlotCount[:], nil)
+		sd.Context.SetAccountData(addr, KeywordSlotSize[:], nil)
+		sd.Context.SetAccountData(addr, KeywordAccounted[:], nil)
+		sd.Context.SetAccountData(addr, KeywordRentHeight[:], nil)
+		if err := sd.Context.DeleteAccount(acc); err != nil {
+			return err
+		}
+		if !accounted {
+			sd.Context.SetAccountData(ContractRegistryAddress, destroyedKey(addr), []byte{1})
+		}
+		unregisterRentContract(sd.Context, addr)
+	}
+	sd.suicides = nil
+	return nil
+}
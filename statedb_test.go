@@ -0,0 +1,132 @@
+package solidity
+
+import (
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/data"
+)
+
+// newTestStateDB returns the StateDB of the context of the loader at the height 1
+func newTestStateDB(ld *testLoader) *StateDB {
+	return &StateDB{
+		Context: data.NewContext(ld),
+		Coord:   common.NewCoordinate(1, 0),
+	}
+}
+
+// testSlot returns the n-th storage key or value of the tests
+func testSlot(n byte) hash.Hash256 {
+	var h hash.Hash256
+	h[31] = n
+	return h
+}
+
+func TestFinalizeStorage(t *testing.T) {
+	sd := newTestStateDB(newTestLoader(t))
+	addr := ContractAddress(testUser(1), 1)
+	sd.CreateAccount(addr, "")
+	for i := byte(1); i <= 3; i++ {
+		sd.SetState(addr, testSlot(i), testSlot(i))
+	}
+	// the deletion moves the last key of the index
+	sd.SetState(addr, testSlot(1), hash.Hash256{})
+	if got := slotKeyAt(sd.Context, addr, 0); got != testSlot(3) {
+		t.Fatalf("got the storage key %v at 0, want %v", got, testSlot(3))
+	}
+
+	sd.Suicide(addr)
+	if err := sd.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+	if is, _ := sd.Context.IsExistAccount(addr); is {
+		t.Fatal("the destroyed account exists")
+	}
+	if IsDestroyedAddress(sd.Context, addr) {
+		t.Fatal("the address of which the storage is deleted is tombstoned")
+	}
+	for i := byte(1); i <= 3; i++ {
+		if v := sd.GetState(addr, testSlot(i)); v != (hash.Hash256{}) {
+			t.Fatalf("the storage slot %d is left: %v", i, v)
+		}
+	}
+	for i := uint64(0); i < 3; i++ {
+		if h := slotKeyAt(sd.Context, addr, i); h != (hash.Hash256{}) {
+			t.Fatalf("the index of the storage key %d is left: %v", i, h)
+		}
+	}
+
+	// the re-created account starts with the empty storage
+	sd.CreateAccount(addr, "")
+	if v := sd.GetState(addr, testSlot(2)); v != (hash.Hash256{}) {
+		t.Fatalf("the re-created account has the storage slot: %v", v)
+	}
+	if cnt, _ := sd.GetSlotCount(addr); cnt != 0 {
+		t.Fatalf("the re-created account has %d slots", cnt)
+	}
+}
+
+func TestFinalizeLegacyContract(t *testing.T) {
+	ld := newTestLoader(t)
+	addr := ld.addLegacyContract(common.NewCoordinate(5, 0), "", stopCode)
+	slot := testSlot(1)
+	ld.setAccountData(addr, slot[:], slot[:])
+	sd := newTestStateDB(ld)
+
+	// the reverted suicide doesn't remove the account
+	sn := sd.Snapshot()
+	sd.Suicide(addr)
+	sd.RevertToSnapshot(sn)
+	if err := sd.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+	if is, _ := sd.Context.IsExistAccount(addr); !is {
+		t.Fatal("the account of the reverted suicide is removed")
+	}
+
+	sd.Suicide(addr)
+	if err := sd.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+	if !IsDestroyedAddress(sd.Context, addr) {
+		t.Fatal("the legacy contract of which the storage is left is not tombstoned")
+	}
+	defer func() {
+		if e := recover(); e != ErrDestroyedAddress {
+			t.Fatalf("got panic %v, want %v", e, ErrDestroyedAddress)
+		}
+	}()
+	sd.CreateAccount(addr, "")
+}
+
+func TestSlotAccounting(t *testing.T) {
+	ld := newTestLoader(t)
+	legacy := ld.addLegacyContract(common.NewCoordinate(5, 0), "", stopCode)
+	sd := newTestStateDB(ld)
+	addr := ContractAddress(testUser(1), 1)
+	sd.CreateAccount(addr, "")
+
+	for i := byte(1); i <= 3; i++ {
+		sd.SetState(addr, testSlot(i), testSlot(i))
+	}
+	// the overwrite of the slot doesn't change the counters
+	sd.SetState(addr, testSlot(2), testSlot(9))
+	sd.SetState(addr, testSlot(3), hash.Hash256{})
+	if cnt, accounted := sd.GetSlotCount(addr); cnt != 2 || !accounted {
+		t.Fatalf("got the slot count %d (%v), want 2 (true)", cnt, accounted)
+	}
+	if size, _ := sd.GetSlotSize(addr); size != 2*64 {
+		t.Fatalf("got the slot size %d, want %d", size, 2*64)
+	}
+
+	// the slots of the legacy contract are not counted and the counters are reported as missing
+	sd.SetState(legacy, testSlot(1), testSlot(1))
+	if cnt, accounted := sd.GetSlotCount(legacy); cnt != 0 || accounted {
+		t.Fatalf("got the slot count %d (%v) of the legacy contract, want 0 (false)", cnt, accounted)
+	}
+	vd := &ViewDB{Loader: ld}
+	if _, accounted := vd.GetSlotSize(legacy); accounted {
+		t.Fatal("the slot size of the legacy contract is reported")
+	}
+}
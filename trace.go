@@ -0,0 +1,184 @@
+package solidity
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/core/transaction"
+	"github.com/fletaio/solidity/vm"
+)
+
+// TraceMode is the tracing mode of the executed contract transactions
+type TraceMode uint8
+
+// trace modes
+const (
+	TraceOff       = TraceMode(0)
+	TraceContracts = TraceMode(1)
+	TraceAll       = TraceMode(2)
+)
+
+// TraceStore stores the traces of the executed transactions by the transaction hash
+// It is provided by the node because the traces are not a part of the chain state
+type TraceStore interface {
+	StoreTrace(TxHash hash.Hash256, trace []byte) error
+	Trace(TxHash hash.Hash256) ([]byte, error)
+}
+
+var (
+	traceMode      = TraceOff
+	traceContracts = map[common.Address]bool{}
+	traceStore     TraceStore
+)
+
+// SetTracing sets the tracing mode of the node
+// The call tree traces of the executed transactions are stored to the store as JSON by the CommitTraces
+// TraceContracts only traces the transactions which target one of the contracts
+func SetTracing(mode TraceMode, store TraceStore, contracts ...common.Address) {
+	traceMode = mode
+	traceStore = store
+	traceContracts = map[common.Address]bool{}
+	for _, addr := range contracts {
+		traceContracts[addr] = true
+	}
+}
+
+// contractTransaction is a transaction which executes the contracts
+type contractTransaction interface {
+	transaction.Transaction
+	contracts(loader data.Loader) []common.Address
+	execute(ctx *data.Context, Fee *amount.Amount, coord *common.Coordinate, tracer vm.Tracer) (interface{}, error)
+}
+
+// isTraced returns true when the tx should be traced by the tracing mode
+func isTraced(loader data.Loader, tx contractTransaction) bool {
+	if traceStore == nil {
+		return false
+	}
+	switch traceMode {
+	case TraceAll:
+		return true
+	case TraceContracts:
+		for _, addr := range tx.contracts(loader) {
+			if traceContracts[addr] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxPendingTraceContexts is the maximum number of the block contexts which have the pending traces
+// The traces of the oldest context are dropped when the node doesn't commit or discard them
+const maxPendingTraceContexts = 64
+
+// pendingTrace is the trace of the executed transaction which is not committed yet
+type pendingTrace struct {
+	TxHash hash.Hash256
+	Trace  []byte
+}
+
+var (
+	pendingLock   sync.Mutex
+	pendingTraces = map[*data.Context][]*pendingTrace{}
+	pendingOrder  []*data.Context
+)
+
+// addPendingTrace keeps the trace of the transaction which is executed by the context until the block is committed
+func addPendingTrace(ctx *data.Context, TxHash hash.Hash256, trace []byte) {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+
+	if _, has := pendingTraces[ctx]; !has {
+		pendingOrder = append(pendingOrder, ctx)
+		for len(pendingOrder) > maxPendingTraceContexts {
+			delete(pendingTraces, pendingOrder[0])
+			pendingOrder = pendingOrder[1:]
+		}
+	}
+	pendingTraces[ctx] = append(pendingTraces[ctx], &pendingTrace{TxHash: TxHash, Trace: trace})
+}
+
+// takePendingTraces removes and returns the pending traces of the context
+func takePendingTraces(ctx *data.Context) []*pendingTrace {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+
+	list := pendingTraces[ctx]
+	delete(pendingTraces, ctx)
+	for i, c := range pendingOrder {
+		if c == ctx {
+			pendingOrder = append(pendingOrder[:i], pendingOrder[i+1:]...)
+			break
+		}
+	}
+	return list
+}
+
+// CommitTraces stores the traces of the transactions which are executed by the context of the committed block
+// It should be called by the node after the block of the context is committed
+func CommitTraces(ctx *data.Context) error {
+	list := takePendingTraces(ctx)
+	if traceStore == nil {
+		return nil
+	}
+	for _, t := range list {
+		if err := traceStore.StoreTrace(t.TxHash, t.Trace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DiscardTraces drops the traces of the transactions which are executed by the context of the block
+// It should be called by the node when the block of the context is not committed
+func DiscardTraces(ctx *data.Context) {
+	takePendingTraces(ctx)
+}
+
+// executeContractTransaction executes the tx and keeps the trace of it by the tracing mode
+// The trace is stored by the CommitTraces when the block of the ctx is committed
+// A failure of the tracing doesn't affect the result of the execution
+func executeContractTransaction(ctx *data.Context, Fee *amount.Amount, tx contractTransaction, coord *common.Coordinate) (interface{}, error) {
+	if !isTraced(ctx, tx) {
+		return tx.execute(ctx, Fee, coord, nil)
+	}
+	tracer := vm.NewCallTracer()
+	ret, err := tx.execute(ctx, Fee, coord, tracer)
+	if err != nil {
+		// the failed transaction is not included in the block
+		return ret, err
+	}
+	if bs, err := json.Marshal(tracer); err != nil {
+		log.Println("Trace", tx.Hash(), err)
+	} else {
+		addPendingTrace(ctx, tx.Hash(), bs)
+	}
+	return ret, nil
+}
+
+// Replay executes the index-th tx of the block at the height on the state of the block with the tracer
+// The loader should load the state before the block and the txs should be the transactions of the block,
+// the preceding txs are executed without the tracer to build the state before the tx
+// The state changes of the replay are not applied to the loader
+func Replay(loader data.Loader, txs []transaction.Transaction, height uint32, index uint16, Fee *amount.Amount, tracer vm.Tracer) (interface{}, error) {
+	if int(index) >= len(txs) {
+		return nil, ErrInvalidTxIndex
+	}
+	tx, is := txs[index].(contractTransaction)
+	if !is {
+		return nil, ErrNotContractTransaction
+	}
+	ctx := data.NewContext(loader)
+	for i := uint16(0); i < index; i++ {
+		if _, err := ctx.Transactor().Execute(ctx, txs[i], common.NewCoordinate(height, i)); err != nil {
+			return nil, err
+		}
+	}
+	return tx.execute(ctx, Fee, common.NewCoordinate(height, index), tracer)
+}
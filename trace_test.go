@@ -0,0 +1,138 @@
+package solidity
+
+import (
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+)
+
+// memTraceStore is the TraceStore in the memory
+type memTraceStore map[hash.Hash256][]byte
+
+func (st memTraceStore) StoreTrace(TxHash hash.Hash256, trace []byte) error {
+	st[TxHash] = trace
+	return nil
+}
+
+func (st memTraceStore) Trace(TxHash hash.Hash256) ([]byte, error) {
+	return st[TxHash], nil
+}
+
+// setTestTracing sets the tracing mode until the test is finished
+func setTestTracing(t *testing.T, mode TraceMode, store TraceStore, contracts ...common.Address) {
+	SetTracing(mode, store, contracts...)
+	t.Cleanup(func() {
+		SetTracing(TraceOff, nil)
+	})
+}
+
+// traceState returns the context which has the store contract and the revert contract
+func traceState(t *testing.T) (*data.Context, common.Address, common.Address) {
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ld.addAccount(testUser(2), 0)
+	ctx := data.NewContext(ld)
+	store, err := createContract(t, ctx, testUser(2), 1, storeCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverter, err := createContract(t, ctx, testUser(2), 2, revertCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx, store, reverter
+}
+
+// executeCall executes the CallContract to the contract by the executeContractTransaction and returns the hash of the tx
+func executeCall(t *testing.T, ctx *data.Context, to common.Address) (hash.Hash256, error) {
+	t.Helper()
+	tx := &CallContract{
+		Seq_:   ctx.Seq(testUser(1)) + 1,
+		From_:  testUser(1),
+		Amount: amount.NewCoinAmount(0, 0),
+		To:     to,
+	}
+	_, err := executeContractTransaction(ctx, amount.NewCoinAmount(0, 0), tx, common.NewCoordinate(1, 0))
+	return tx.Hash(), err
+}
+
+func TestCommitTraces(t *testing.T) {
+	st := memTraceStore{}
+	ctx, store, reverter := traceState(t)
+	setTestTracing(t, TraceAll, st)
+
+	TxHash, err := executeCall(t, ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the failed transaction is not traced
+	if _, err := executeCall(t, ctx, reverter); err == nil {
+		t.Fatal("the call to the revert contract succeeds")
+	}
+	if len(st) > 0 {
+		t.Fatal("the trace is stored before the commit")
+	}
+	if err := CommitTraces(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(st) != 1 || len(st[TxHash]) == 0 {
+		t.Fatalf("got %d traces, want the trace of the tx", len(st))
+	}
+
+	// the committed traces are flushed
+	delete(st, TxHash)
+	if err := CommitTraces(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(st) > 0 {
+		t.Fatal("the committed trace is stored again")
+	}
+}
+
+func TestDiscardTraces(t *testing.T) {
+	st := memTraceStore{}
+	ctx, store, _ := traceState(t)
+	setTestTracing(t, TraceAll, st)
+
+	if _, err := executeCall(t, ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	DiscardTraces(ctx)
+	if err := CommitTraces(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(st) > 0 {
+		t.Fatal("the discarded trace is stored")
+	}
+}
+
+func TestTraceContracts(t *testing.T) {
+	st := memTraceStore{}
+	ctx, store, reverter := traceState(t)
+	setTestTracing(t, TraceContracts, st, reverter)
+
+	if _, err := executeCall(t, ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	if err := CommitTraces(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(st) > 0 {
+		t.Fatal("the call to the contract which is not traced is stored")
+	}
+
+	SetTracing(TraceContracts, st, store)
+	TxHash, err := executeCall(t, ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CommitTraces(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(st[TxHash]) == 0 {
+		t.Fatal("the call to the traced contract is not stored")
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"io"
-	"math/big"
-	"time"
 
 	"github.com/fletaio/common"
 	"github.com/fletaio/common/hash"
@@ -29,73 +27,21 @@ func init() {
 		if tx.Seq() <= loader.Seq(tx.From()) {
 			return ErrInvalidSequence
 		}
+		if len(tx.ToName) > 0 && tx.To != (common.Address{}) {
+			return ErrInvalidAccountName
+		}
 
 		fromAcc, err := loader.Account(tx.From())
 		if err != nil {
 			return err
 		}
 
-		if err := loader.Accounter().Validate(loader, fromAcc, signers); err != nil {
+		if err := validateSigners(loader, fromAcc, signers); err != nil {
 			return err
 		}
 		return nil
-	}, func(ctx *data.Context, Fee *amount.Amount, t transaction.Transaction, coord *common.Coordinate) (ret interface{}, rerr error) {
-		defer func() {
-			if e := recover(); e != nil {
-				if err, is := e.(error); is {
-					rerr = err
-				} else {
-					rerr = ErrVirtualMachinePanic
-				}
-			}
-		}()
-
-		tx := t.(*CallContract)
-		sn := ctx.Snapshot()
-		defer ctx.Revert(sn)
-
-		if tx.Seq() != ctx.Seq(tx.From())+1 {
-			return nil, ErrInvalidSequence
-		}
-		ctx.AddSeq(tx.From())
-
-		fromAcc, err := ctx.Account(tx.From())
-		if err != nil {
-			return nil, err
-		}
-		if err := fromAcc.SubBalance(Fee); err != nil {
-			return nil, err
-		}
-
-		statedb := &StateDB{
-			Context: ctx,
-			Coord:   coord,
-		}
-		logconfig := &vm.LogConfig{
-			DisableMemory: false,
-			DisableStack:  false,
-			Debug:         false,
-		}
-		vmCfg := vm.Config{
-			Tracer: vm.NewStructLogger(logconfig),
-			Debug:  false,
-		}
-		vctx := vm.Context{
-			CanTransfer: CanTransfer,
-			Transfer:    Transfer,
-			GetHash:     func(uint64) hash.Hash256 { return hash.Hash256{} },
-			Origin:      tx.From(),
-			BlockNumber: new(big.Int).SetUint64(100),
-			Time:        big.NewInt(time.Now().Unix()),
-			Difficulty:  new(big.Int),
-		}
-		evm := vm.NewEVM(vctx, statedb, vmCfg)
-		ret, err = evm.Call(vm.AccountRef(tx.From()), tx.To, append(tx.Method, tx.Params...), tx.Amount)
-		if err != nil {
-			return nil, err
-		}
-		ctx.Commit(sn)
-		return ret, nil
+	}, func(ctx *data.Context, Fee *amount.Amount, t transaction.Transaction, coord *common.Coordinate) (interface{}, error) {
+		return executeContractTransaction(ctx, Fee, t.(contractTransaction), coord)
 	})
 }
 
@@ -107,6 +53,7 @@ type CallContract struct {
 	From_  common.Address
 	Amount *amount.Amount
 	To     common.Address
+	ToName string
 	Method []byte
 	Params []byte
 }
@@ -159,6 +106,11 @@ func (tx *CallContract) WriteTo(w io.Writer) (int64, error) {
 	} else {
 		wrote += n
 	}
+	if n, err := util.WriteString(w, tx.ToName); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
 	if n, err := util.WriteBytes(w, tx.Method); err != nil {
 		return wrote, err
 	} else {
@@ -201,6 +153,12 @@ func (tx *CallContract) ReadFrom(r io.Reader) (int64, error) {
 	} else {
 		read += n
 	}
+	if v, n, err := util.ReadString(r); err != nil {
+		return read, err
+	} else {
+		read += n
+		tx.ToName = v
+	}
 	if bs, n, err := util.ReadBytes(r); err != nil {
 		return read, err
 	} else {
@@ -262,6 +220,13 @@ func (tx *CallContract) MarshalJSON() ([]byte, error) {
 		buffer.Write(bs)
 	}
 	buffer.WriteString(`,`)
+	buffer.WriteString(`"to_name":`)
+	if bs, err := json.Marshal(tx.ToName); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
 	buffer.WriteString(`"method":`)
 	if len(tx.Method) == 0 {
 		buffer.WriteString(`null`)
@@ -282,3 +247,73 @@ func (tx *CallContract) MarshalJSON() ([]byte, error) {
 	buffer.WriteString(`}`)
 	return buffer.Bytes(), nil
 }
+
+// execute calls the contract method with the tracer, the tracer can be nil
+func (tx *CallContract) execute(ctx *data.Context, Fee *amount.Amount, coord *common.Coordinate, tracer vm.Tracer) (ret interface{}, rerr error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if err, is := e.(error); is {
+				rerr = err
+			} else {
+				rerr = ErrVirtualMachinePanic
+			}
+		}
+	}()
+
+	sn := ctx.Snapshot()
+	defer ctx.Revert(sn)
+
+	if tx.Seq() != ctx.Seq(tx.From())+1 {
+		return nil, ErrInvalidSequence
+	}
+	ctx.AddSeq(tx.From())
+
+	fromAcc, err := ctx.Account(tx.From())
+	if err != nil {
+		return nil, err
+	}
+	if err := fromAcc.SubBalance(Fee); err != nil {
+		return nil, err
+	}
+
+	statedb := &StateDB{
+		Context: ctx,
+		Coord:   coord,
+	}
+	to, err := callTarget(ctx, tx.To, tx.ToName)
+	if err != nil {
+		return nil, err
+	}
+	cfg := execConfig
+	evm := newEVM(cfg, ctx, statedb, coord.Height, tx.From(), tracer)
+	gctx, cancel := cfg.txContext()
+	defer cancel()
+	if err := runEVM(gctx, evm, func() (err error) {
+		ret, err = evm.Call(vm.AccountRef(tx.From()), to, append(tx.Method, tx.Params...), tx.Amount)
+		return
+	}); err != nil {
+		return nil, err
+	}
+	if err := statedb.Finalize(); err != nil {
+		return nil, err
+	}
+	ctx.Commit(sn)
+	return ret, nil
+}
+
+// contracts returns the address of the contract which is called by the tx
+func (tx *CallContract) contracts(loader data.Loader) []common.Address {
+	to, err := callTarget(loader, tx.To, tx.ToName)
+	if err != nil {
+		return nil
+	}
+	return []common.Address{to}
+}
+
+// callTarget returns the address of the called contract which is given by the address or the account name
+func callTarget(loader data.Loader, to common.Address, toName string) (common.Address, error) {
+	if len(toName) > 0 {
+		return loader.AddressByName(toName)
+	}
+	return to, nil
+}
@@ -0,0 +1,128 @@
+package solidity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/core/transaction"
+	"github.com/fletaio/solidity/vm"
+)
+
+// resolveCallCode resolves the account name of the input by the name resolver
+// and calls the resolved address with the value of the call
+var resolveCallCode = []byte{
+	byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLDATACOPY),
+	byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.CALLDATASIZE), byte(vm.PUSH1), 0, byte(vm.PUSH1), 0x10, byte(vm.PUSH1), 0, byte(vm.STATICCALL), byte(vm.POP),
+	byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.CALLVALUE), byte(vm.PUSH1), 0, byte(vm.MLOAD), byte(vm.PUSH1), 0, byte(vm.CALL),
+	byte(vm.PUSH1), 0, byte(vm.MSTORE), byte(vm.PUSH1), 32, byte(vm.PUSH1), 0, byte(vm.RETURN),
+}
+
+// createNamedContract executes the CreateContract of the runtime code with the account name
+func createNamedContract(t *testing.T, ctx *data.Context, from common.Address, name string, runtime []byte) common.Address {
+	t.Helper()
+	tx := &CreateContract{
+		Base:   transaction.Base{Type_: createContractType},
+		Seq_:   ctx.Seq(from) + 1,
+		From_:  from,
+		Amount: amount.NewCoinAmount(0, 0),
+		Salt:   ctx.Seq(from) + 1,
+		Name:   name,
+		Code:   initCode(runtime),
+	}
+	if _, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(ctx.TargetHeight(), 0)); err != nil {
+		t.Fatal(err)
+	}
+	return ContractAddress(from, tx.Salt)
+}
+
+func TestCallContractSerialization(t *testing.T) {
+	tx := &CallContract{
+		Seq_:   4,
+		From_:  testUser(1),
+		Amount: amount.NewCoinAmount(1, 0),
+		ToName: "store",
+		Method: []byte{1, 2, 3, 4},
+		Params: []byte{5, 6},
+	}
+	var buffer bytes.Buffer
+	if _, err := tx.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	read := &CallContract{Amount: amount.NewCoinAmount(0, 0)}
+	if _, err := read.ReadFrom(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if read.Hash() != tx.Hash() || read.ToName != tx.ToName || !bytes.Equal(read.Params, tx.Params) {
+		t.Fatalf("the read tx %+v is different from %+v", read, tx)
+	}
+}
+
+func TestCallContractByName(t *testing.T) {
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ld.addAccount(testUser(2), 0)
+	ctx := data.NewContext(ld)
+	store := createNamedContract(t, ctx, testUser(2), "store", storeCode)
+
+	tx := ld.newTx(t, "solidity.CallContract").(*CallContract)
+	tx.Seq_ = 1
+	tx.From_ = testUser(1)
+	tx.Amount = amount.NewCoinAmount(0, 0)
+	tx.To = store
+	tx.ToName = "store"
+	if err := ld.transactor.Validate(ld, tx, nil); err != ErrInvalidAccountName {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidAccountName)
+	}
+	tx.To = common.Address{}
+	if _, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if v := (&StateDB{Context: ctx}).GetState(store, hash.Hash256{}); v == (hash.Hash256{}) {
+		t.Fatal("the contract of the name is not called")
+	}
+	if addrs := tx.contracts(ctx); len(addrs) != 1 || addrs[0] != store {
+		t.Fatalf("got the contracts %v, want %v", addrs, store)
+	}
+}
+
+func TestCallResolvedName(t *testing.T) {
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ld.addAccount(testUser(2), 0)
+	// the address of the legacy contract has the leading zero bytes
+	bob := ld.addLegacyContract(common.NewCoordinate(5, 0), "bob", storeCode)
+	if bob[0] != 0 {
+		t.Fatalf("the address %v doesn't have the leading zero", bob)
+	}
+	ctx := data.NewContext(ld)
+	caller, err := createContract(t, ctx, testUser(2), 1, resolveCallCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := &CallContract{
+		Base:   transaction.Base{Type_: callContractType},
+		Seq_:   1,
+		From_:  testUser(1),
+		Amount: amount.NewCoinAmount(2, 0),
+		To:     caller,
+		Params: []byte("bob"),
+	}
+	ret, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs := ret.([]byte); len(bs) != 32 || bs[31] != 1 {
+		t.Fatalf("the call of the resolved address failed: %x", bs)
+	}
+	if b := balanceOf(t, ctx, bob); !b.Equal(amount.NewCoinAmount(2, 0)) {
+		t.Fatalf("got the balance %v of the resolved contract, want 2 coins", b)
+	}
+	if v := (&StateDB{Context: ctx}).GetState(bob, hash.Hash256{}); v == (hash.Hash256{}) {
+		t.Fatal("the resolved contract is not called")
+	}
+}
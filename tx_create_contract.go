@@ -5,8 +5,6 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"io"
-	"math/big"
-	"time"
 
 	"github.com/fletaio/common"
 	"github.com/fletaio/common/hash"
@@ -35,6 +33,7 @@ func init() {
 			Base: transaction.Base{
 				Type_: t,
 			},
+			Amount: amount.NewCoinAmount(0, 0),
 		}
 	}, func(loader data.Loader, t transaction.Transaction, signers []common.PublicHash) error {
 		tx := t.(*CreateContract)
@@ -42,90 +41,28 @@ func init() {
 			return ErrInvalidSequence
 		}
 
-		if len(signers) > 1 {
-			return ErrInvalidSignerCount
-		}
-		if !allowedKeyMap[signers[0]] {
-			return ErrNotAllowed
+		if tx.Amount.IsMinus() {
+			return ErrInvalidAmount
 		}
 
 		fromAcc, err := loader.Account(tx.From())
 		if err != nil {
 			return err
 		}
-
-		if err := loader.Accounter().Validate(loader, fromAcc, signers); err != nil {
-			return err
-		}
-		return nil
-	}, func(ctx *data.Context, Fee *amount.Amount, t transaction.Transaction, coord *common.Coordinate) (ret interface{}, rerr error) {
-		defer func() {
-			if e := recover(); e != nil {
-				if err, is := e.(error); is {
-					rerr = err
-				} else {
-					rerr = ErrVirtualMachinePanic
-				}
-			}
-		}()
-
-		tx := t.(*CreateContract)
-		sn := ctx.Snapshot()
-		defer ctx.Revert(sn)
-
-		if tx.Seq() != ctx.Seq(tx.From())+1 {
-			return nil, ErrInvalidSequence
-		}
-		ctx.AddSeq(tx.From())
-
-		fromAcc, err := ctx.Account(tx.From())
-		if err != nil {
-			return nil, err
-		}
-		if err := fromAcc.SubBalance(Fee); err != nil {
-			return nil, err
-		}
-
-		contAddr := common.NewAddress(coord, 0)
-		if is, err := ctx.IsExistAccount(contAddr); err != nil {
-			return nil, err
-		} else if is {
-			return nil, ErrExistAddress
-		} else if isn, err := ctx.IsExistAccountName(tx.Name); err != nil {
-			return nil, err
-		} else if isn {
-			return nil, ErrExistAccountName
+		if fromAcc.Balance().Less(tx.Amount) {
+			return ErrInsuffcientBalance
 		}
 
-		statedb := &StateDB{
-			Context: ctx,
-			Coord:   coord,
-		}
-		logconfig := &vm.LogConfig{
-			DisableMemory: false,
-			DisableStack:  false,
-			Debug:         false,
-		}
-		vmCfg := vm.Config{
-			Tracer: vm.NewStructLogger(logconfig),
-			Debug:  false,
-		}
-		vctx := vm.Context{
-			CanTransfer: CanTransfer,
-			Transfer:    Transfer,
-			GetHash:     func(uint64) hash.Hash256 { return hash.Hash256{} },
-			Origin:      tx.From(),
-			BlockNumber: new(big.Int).SetUint64(100),
-			Time:        big.NewInt(time.Now().Unix()),
-			Difficulty:  new(big.Int),
+		// the creator can be a multisig account, and its key set should have the allowed key
+		if err := validateSigners(loader, fromAcc, signers); err != nil {
+			return err
 		}
-		evm := vm.NewEVM(vctx, statedb, vmCfg)
-		code, err := evm.Create(vm.AccountRef(tx.From()), contAddr, tx.Name, append(tx.Code, tx.Params...), amount.NewCoinAmount(0, 0))
-		if err != nil {
-			return nil, err
+		if !isAllowedAccount(loader, fromAcc) {
+			return ErrNotAllowed
 		}
-		ctx.Commit(sn)
-		return code, nil
+		return nil
+	}, func(ctx *data.Context, Fee *amount.Amount, t transaction.Transaction, coord *common.Coordinate) (interface{}, error) {
+		return executeContractTransaction(ctx, Fee, t.(contractTransaction), coord)
 	})
 }
 
@@ -135,6 +72,8 @@ type CreateContract struct {
 	transaction.Base
 	Seq_   uint64
 	From_  common.Address
+	Amount *amount.Amount
+	Salt   uint64
 	Name   string
 	Code   []byte
 	Params []byte
@@ -178,6 +117,16 @@ func (tx *CreateContract) WriteTo(w io.Writer) (int64, error) {
 	} else {
 		wrote += n
 	}
+	if n, err := tx.Amount.WriteTo(w); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
+	if n, err := util.WriteUint64(w, tx.Salt); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
 	if n, err := util.WriteString(w, tx.Name); err != nil {
 		return wrote, err
 	} else {
@@ -215,6 +164,17 @@ func (tx *CreateContract) ReadFrom(r io.Reader) (int64, error) {
 	} else {
 		read += n
 	}
+	if n, err := tx.Amount.ReadFrom(r); err != nil {
+		return read, err
+	} else {
+		read += n
+	}
+	if v, n, err := util.ReadUint64(r); err != nil {
+		return read, err
+	} else {
+		read += n
+		tx.Salt = v
+	}
 	if v, n, err := util.ReadString(r); err != nil {
 		return read, err
 	} else {
@@ -268,6 +228,27 @@ func (tx *CreateContract) MarshalJSON() ([]byte, error) {
 		buffer.Write(bs)
 	}
 	buffer.WriteString(`,`)
+	buffer.WriteString(`"amount":`)
+	if bs, err := tx.Amount.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"salt":`)
+	if bs, err := json.Marshal(tx.Salt); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"name":`)
+	if bs, err := json.Marshal(tx.Name); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
 	buffer.WriteString(`"code":`)
 	if len(tx.Code) == 0 {
 		buffer.WriteString(`null`)
@@ -288,3 +269,75 @@ func (tx *CreateContract) MarshalJSON() ([]byte, error) {
 	buffer.WriteString(`}`)
 	return buffer.Bytes(), nil
 }
+
+// execute creates the contract with the tracer, the tracer can be nil
+func (tx *CreateContract) execute(ctx *data.Context, Fee *amount.Amount, coord *common.Coordinate, tracer vm.Tracer) (ret interface{}, rerr error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if err, is := e.(error); is {
+				rerr = err
+			} else {
+				rerr = ErrVirtualMachinePanic
+			}
+		}
+	}()
+
+	sn := ctx.Snapshot()
+	defer ctx.Revert(sn)
+
+	if tx.Seq() != ctx.Seq(tx.From())+1 {
+		return nil, ErrInvalidSequence
+	}
+	ctx.AddSeq(tx.From())
+
+	fromAcc, err := ctx.Account(tx.From())
+	if err != nil {
+		return nil, err
+	}
+	if err := fromAcc.SubBalance(Fee); err != nil {
+		return nil, err
+	}
+
+	contAddr := ContractAddress(tx.From(), tx.Salt)
+	if len(ctx.AccountData(ContractRegistryAddress, contractRegistryKey(contAddr))) > 0 {
+		return nil, ErrExistAddress
+	} else if IsDestroyedAddress(ctx, contAddr) {
+		return nil, ErrDestroyedAddress
+	} else if is, err := ctx.IsExistAccount(contAddr); err != nil {
+		return nil, err
+	} else if is {
+		return nil, ErrExistAddress
+	} else if isn, err := ctx.IsExistAccountName(tx.Name); err != nil {
+		return nil, err
+	} else if isn {
+		return nil, ErrExistAccountName
+	}
+
+	ctx.SetAccountData(ContractRegistryAddress, contractRegistryKey(contAddr), contAddr[:])
+
+	statedb := &StateDB{
+		Context: ctx,
+		Coord:   coord,
+	}
+	cfg := execConfig
+	evm := newEVM(cfg, ctx, statedb, coord.Height, tx.From(), tracer)
+	gctx, cancel := cfg.txContext()
+	defer cancel()
+	var code []byte
+	if err := runEVM(gctx, evm, func() (err error) {
+		code, err = evm.Create(vm.AccountRef(tx.From()), contAddr, tx.Name, append(tx.Code, tx.Params...), tx.Amount)
+		return
+	}); err != nil {
+		return nil, err
+	}
+	if err := statedb.Finalize(); err != nil {
+		return nil, err
+	}
+	ctx.Commit(sn)
+	return code, nil
+}
+
+// contracts returns the address of the contract which is created by the tx
+func (tx *CreateContract) contracts(loader data.Loader) []common.Address {
+	return []common.Address{ContractAddress(tx.From(), tx.Salt)}
+}
@@ -0,0 +1,136 @@
+package solidity
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/core/transaction"
+	"github.com/fletaio/solidity/vm"
+)
+
+// stopCode is the runtime code which stops without any change
+var stopCode = []byte{byte(vm.STOP)}
+
+func TestCreateContractSerialization(t *testing.T) {
+	tx := &CreateContract{
+		Seq_:   3,
+		From_:  testUser(1),
+		Amount: amount.NewCoinAmount(2, 5),
+		Salt:   7,
+		Name:   "token",
+		Code:   []byte{0x60, 0x00},
+		Params: []byte{0x01},
+	}
+	var buffer bytes.Buffer
+	if _, err := tx.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	read := &CreateContract{Amount: amount.NewCoinAmount(0, 0)}
+	if _, err := read.ReadFrom(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if read.Hash() != tx.Hash() {
+		t.Fatalf("the read tx %+v is different from %+v", read, tx)
+	}
+	if read.Salt != tx.Salt || read.Name != tx.Name || !read.Amount.Equal(tx.Amount) {
+		t.Fatalf("the read tx %+v is different from %+v", read, tx)
+	}
+
+	bs, err := tx.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(bs, &m); err != nil {
+		t.Fatal(err, string(bs))
+	}
+	if m["name"] != "token" || m["salt"] != float64(7) {
+		t.Fatalf("the JSON doesn't have the name and the salt: %s", bs)
+	}
+}
+
+func TestContractAddress(t *testing.T) {
+	addr := ContractAddress(testUser(1), 1)
+	if addr != ContractAddress(testUser(1), 1) {
+		t.Fatal("the contract address is not deterministic")
+	}
+	if addr == ContractAddress(testUser(1), 2) || addr == ContractAddress(testUser(2), 1) {
+		t.Fatal("the contract addresses of the different creators or salts are same")
+	}
+	if addr[0] != contractAddressPrefix || vm.IsReservedAddress(addr) {
+		t.Fatalf("the contract address %v is not in the range of the contracts", addr)
+	}
+}
+
+func TestCreateContractExistAddress(t *testing.T) {
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ctx := data.NewContext(ld)
+
+	runtime := stopCode
+	addr, err := createContract(t, ctx, testUser(1), 1, runtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if is, _ := ctx.IsExistAccount(addr); !is {
+		t.Fatal("the contract account is not created")
+	}
+	if !bytes.Equal((&StateDB{Context: ctx}).GetCode(addr), runtime) {
+		t.Fatal("the runtime code is not deployed")
+	}
+	if _, err := createContract(t, ctx, testUser(1), 1, runtime); err != ErrExistAddress {
+		t.Fatalf("got error %v, want %v", err, ErrExistAddress)
+	}
+	// the failed transaction doesn't change the sequence
+	if seq := ctx.Seq(testUser(1)); seq != 1 {
+		t.Fatalf("got sequence %d, want 1", seq)
+	}
+	if _, err := createContract(t, ctx, testUser(1), 2, runtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateContractEndowment(t *testing.T) {
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ctx := data.NewContext(ld)
+
+	tx := &CreateContract{
+		Base:   transaction.Base{Type_: createContractType},
+		Seq_:   1,
+		From_:  testUser(1),
+		Amount: amount.NewCoinAmount(3, 0),
+		Salt:   1,
+		Code:   initCode(stopCode),
+	}
+	if _, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	addr := ContractAddress(testUser(1), 1)
+	if b := balanceOf(t, ctx, addr); !b.Equal(amount.NewCoinAmount(3, 0)) {
+		t.Fatalf("got the contract balance %v, want 3 coins", b)
+	}
+	// the endowment is subtracted from the creator
+	if b := balanceOf(t, ctx, testUser(1)); !b.Equal(amount.NewCoinAmount(7, 0)) {
+		t.Fatalf("got the creator balance %v, want 7 coins", b)
+	}
+
+	tx = &CreateContract{
+		Base:   transaction.Base{Type_: createContractType},
+		Seq_:   2,
+		From_:  testUser(1),
+		Amount: amount.NewCoinAmount(8, 0),
+		Salt:   2,
+		Code:   tx.Code,
+	}
+	if _, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(1, 1)); err != vm.ErrInsufficientBalance {
+		t.Fatalf("got error %v, want %v", err, vm.ErrInsufficientBalance)
+	}
+	if is, _ := ctx.IsExistAccount(ContractAddress(testUser(1), 2)); is {
+		t.Fatal("the contract is created without the endowment")
+	}
+}
@@ -0,0 +1,416 @@
+package solidity
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/common/util"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/core/transaction"
+	"github.com/fletaio/solidity/vm"
+)
+
+// MaxMultiCallCount is the maximum number of calls in the MultiCall
+const MaxMultiCallCount = 255
+
+func init() {
+	data.RegisterTransaction("solidity.MultiCall", func(t transaction.Type) transaction.Transaction {
+		return &MultiCall{
+			Base: transaction.Base{
+				Type_: t,
+			},
+		}
+	}, func(loader data.Loader, t transaction.Transaction, signers []common.PublicHash) error {
+		tx := t.(*MultiCall)
+		if tx.Seq() <= loader.Seq(tx.From()) {
+			return ErrInvalidSequence
+		}
+		if len(tx.Calls) == 0 || len(tx.Calls) > MaxMultiCallCount {
+			return ErrInvalidCallCount
+		}
+
+		sum := amount.NewCoinAmount(0, 0)
+		for _, c := range tx.Calls {
+			if c.Amount == nil || c.Amount.IsMinus() {
+				return ErrInvalidAmount
+			}
+			if len(c.ToName) > 0 && c.To != (common.Address{}) {
+				return ErrInvalidAccountName
+			}
+			sum = sum.Add(c.Amount)
+		}
+
+		fromAcc, err := loader.Account(tx.From())
+		if err != nil {
+			return err
+		}
+		if fromAcc.Balance().Less(sum) {
+			return ErrInsuffcientBalance
+		}
+
+		if err := validateSigners(loader, fromAcc, signers); err != nil {
+			return err
+		}
+		return nil
+	}, func(ctx *data.Context, Fee *amount.Amount, t transaction.Transaction, coord *common.Coordinate) (interface{}, error) {
+		return executeContractTransaction(ctx, Fee, t.(contractTransaction), coord)
+	})
+}
+
+// ContractCall is a call of the MultiCall
+// The called contract is given by the To or the ToName
+type ContractCall struct {
+	Amount *amount.Amount
+	To     common.Address
+	ToName string
+	Method []byte
+	Params []byte
+}
+
+// WriteTo is a serialization function
+func (c *ContractCall) WriteTo(w io.Writer) (int64, error) {
+	var wrote int64
+	if c.Amount == nil {
+		return wrote, ErrInvalidAmount
+	}
+	if n, err := c.Amount.WriteTo(w); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
+	if n, err := c.To.WriteTo(w); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
+	if n, err := util.WriteString(w, c.ToName); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
+	if n, err := util.WriteBytes(w, c.Method); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
+	if n, err := util.WriteBytes(w, c.Params); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
+	return wrote, nil
+}
+
+// ReadFrom is a deserialization function
+func (c *ContractCall) ReadFrom(r io.Reader) (int64, error) {
+	var read int64
+	c.Amount = amount.NewCoinAmount(0, 0)
+	if n, err := c.Amount.ReadFrom(r); err != nil {
+		return read, err
+	} else {
+		read += n
+	}
+	if n, err := c.To.ReadFrom(r); err != nil {
+		return read, err
+	} else {
+		read += n
+	}
+	if v, n, err := util.ReadString(r); err != nil {
+		return read, err
+	} else {
+		read += n
+		c.ToName = v
+	}
+	if bs, n, err := util.ReadBytes(r); err != nil {
+		return read, err
+	} else {
+		read += n
+		c.Method = bs
+	}
+	if bs, n, err := util.ReadBytes(r); err != nil {
+		return read, err
+	} else {
+		read += n
+		c.Params = bs
+	}
+	return read, nil
+}
+
+// MarshalJSON is a marshaler function
+func (c *ContractCall) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{`)
+	buffer.WriteString(`"amount":`)
+	if bs, err := c.Amount.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"to":`)
+	if bs, err := c.To.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"to_name":`)
+	if bs, err := json.Marshal(c.ToName); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"method":`)
+	if len(c.Method) == 0 {
+		buffer.WriteString(`null`)
+	} else {
+		buffer.WriteString(`"`)
+		buffer.WriteString(hex.EncodeToString(c.Method))
+		buffer.WriteString(`"`)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"params":`)
+	if len(c.Params) == 0 {
+		buffer.WriteString(`null`)
+	} else {
+		buffer.WriteString(`"`)
+		buffer.WriteString(hex.EncodeToString(c.Params))
+		buffer.WriteString(`"`)
+	}
+	buffer.WriteString(`}`)
+	return buffer.Bytes(), nil
+}
+
+// MultiCall is a solidity.MultiCall
+// It is used to call the contract methods atomically
+type MultiCall struct {
+	transaction.Base
+	Seq_  uint64
+	From_ common.Address
+	Calls []*ContractCall
+}
+
+// IsUTXO returns false
+func (tx *MultiCall) IsUTXO() bool {
+	return false
+}
+
+// From returns the creator of the transaction
+func (tx *MultiCall) From() common.Address {
+	return tx.From_
+}
+
+// Seq returns the sequence of the transaction
+func (tx *MultiCall) Seq() uint64 {
+	return tx.Seq_
+}
+
+// Hash returns the hash value of it
+func (tx *MultiCall) Hash() hash.Hash256 {
+	return hash.DoubleHashByWriterTo(tx)
+}
+
+// WriteTo is a serialization function
+func (tx *MultiCall) WriteTo(w io.Writer) (int64, error) {
+	var wrote int64
+	if n, err := tx.Base.WriteTo(w); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
+	if n, err := util.WriteUint64(w, tx.Seq_); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
+	if n, err := tx.From_.WriteTo(w); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+	}
+	if len(tx.Calls) > MaxMultiCallCount {
+		return wrote, ErrInvalidCallCount
+	}
+	if n, err := util.WriteUint8(w, uint8(len(tx.Calls))); err != nil {
+		return wrote, err
+	} else {
+		wrote += n
+		for _, c := range tx.Calls {
+			if n, err := c.WriteTo(w); err != nil {
+				return wrote, err
+			} else {
+				wrote += n
+			}
+		}
+	}
+	return wrote, nil
+}
+
+// ReadFrom is a deserialization function
+func (tx *MultiCall) ReadFrom(r io.Reader) (int64, error) {
+	var read int64
+	if n, err := tx.Base.ReadFrom(r); err != nil {
+		return read, err
+	} else {
+		read += n
+	}
+	if v, n, err := util.ReadUint64(r); err != nil {
+		return read, err
+	} else {
+		read += n
+		tx.Seq_ = v
+	}
+	if n, err := tx.From_.ReadFrom(r); err != nil {
+		return read, err
+	} else {
+		read += n
+	}
+	if Len, n, err := util.ReadUint8(r); err != nil {
+		return read, err
+	} else {
+		read += n
+		tx.Calls = make([]*ContractCall, 0, Len)
+		for i := 0; i < int(Len); i++ {
+			c := &ContractCall{}
+			if n, err := c.ReadFrom(r); err != nil {
+				return read, err
+			} else {
+				read += n
+			}
+			tx.Calls = append(tx.Calls, c)
+		}
+	}
+	return read, nil
+}
+
+// MarshalJSON is a marshaler function
+func (tx *MultiCall) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString(`{`)
+	buffer.WriteString(`"type":`)
+	if bs, err := json.Marshal(tx.Type_); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"timestamp":`)
+	if bs, err := json.Marshal(tx.Timestamp_); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"seq":`)
+	if bs, err := json.Marshal(tx.Seq_); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"from":`)
+	if bs, err := tx.From_.MarshalJSON(); err != nil {
+		return nil, err
+	} else {
+		buffer.Write(bs)
+	}
+	buffer.WriteString(`,`)
+	buffer.WriteString(`"calls":`)
+	buffer.WriteString(`[`)
+	for i, c := range tx.Calls {
+		if i > 0 {
+			buffer.WriteString(`,`)
+		}
+		if bs, err := c.MarshalJSON(); err != nil {
+			return nil, err
+		} else {
+			buffer.Write(bs)
+		}
+	}
+	buffer.WriteString(`]`)
+	buffer.WriteString(`}`)
+	return buffer.Bytes(), nil
+}
+
+// execute calls the contract methods in order with the tracer, the tracer can be nil
+func (tx *MultiCall) execute(ctx *data.Context, Fee *amount.Amount, coord *common.Coordinate, tracer vm.Tracer) (ret interface{}, rerr error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if err, is := e.(error); is {
+				rerr = err
+			} else {
+				rerr = ErrVirtualMachinePanic
+			}
+		}
+	}()
+
+	sn := ctx.Snapshot()
+	defer ctx.Revert(sn)
+
+	if tx.Seq() != ctx.Seq(tx.From())+1 {
+		return nil, ErrInvalidSequence
+	}
+	ctx.AddSeq(tx.From())
+
+	fromAcc, err := ctx.Account(tx.From())
+	if err != nil {
+		return nil, err
+	}
+	if err := fromAcc.SubBalance(Fee); err != nil {
+		return nil, err
+	}
+
+	statedb := &StateDB{
+		Context: ctx,
+		Coord:   coord,
+	}
+	tos := make([]common.Address, 0, len(tx.Calls))
+	for _, c := range tx.Calls {
+		to, err := callTarget(ctx, c.To, c.ToName)
+		if err != nil {
+			return nil, err
+		}
+		tos = append(tos, to)
+	}
+	cfg := execConfig
+	evm := newEVM(cfg, ctx, statedb, coord.Height, tx.From(), tracer)
+	gctx, cancel := cfg.txContext()
+	defer cancel()
+	rets := make([][]byte, 0, len(tx.Calls))
+	if err := runEVM(gctx, evm, func() error {
+		return evm.Batch(tx.From(), func() error {
+			for i, c := range tx.Calls {
+				ret, err := evm.Call(vm.AccountRef(tx.From()), tos[i], append(c.Method, c.Params...), c.Amount)
+				if err != nil {
+					return err
+				}
+				rets = append(rets, ret)
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	if err := statedb.Finalize(); err != nil {
+		return nil, err
+	}
+	ctx.Commit(sn)
+	return rets, nil
+}
+
+// contracts returns the addresses of the contracts which are called by the tx
+func (tx *MultiCall) contracts(loader data.Loader) []common.Address {
+	addrs := make([]common.Address, 0, len(tx.Calls))
+	for _, c := range tx.Calls {
+		if to, err := callTarget(loader, c.To, c.ToName); err == nil {
+			addrs = append(addrs, to)
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,167 @@
+package solidity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
+	"github.com/fletaio/core/transaction"
+	"github.com/fletaio/solidity/vm"
+)
+
+// the runtime codes of the calls of the tests
+var (
+	// storeCode stores 1 to the slot 0
+	storeCode = []byte{byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.SSTORE), byte(vm.STOP)}
+	// revertCode always reverts
+	revertCode = []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}
+)
+
+func TestMultiCallSerialization(t *testing.T) {
+	tx := &MultiCall{
+		Seq_:  2,
+		From_: testUser(1),
+		Calls: []*ContractCall{
+			{Amount: amount.NewCoinAmount(1, 0), To: testUser(2), Method: []byte{1, 2, 3, 4}},
+			{Amount: amount.NewCoinAmount(0, 0), To: testUser(3), Params: []byte{5}},
+		},
+	}
+	var buffer bytes.Buffer
+	if _, err := tx.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	read := &MultiCall{}
+	if _, err := read.ReadFrom(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if read.Hash() != tx.Hash() || len(read.Calls) != 2 || read.Calls[1].To != testUser(3) {
+		t.Fatalf("the read tx %+v is different from %+v", read, tx)
+	}
+
+	tx.Calls[0].Amount = nil
+	if _, err := tx.WriteTo(&buffer); err != ErrInvalidAmount {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidAmount)
+	}
+}
+
+// multiCallState returns the context which has the store contract and the revert contract
+func multiCallState(t *testing.T) (*data.Context, common.Address, common.Address) {
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ld.addAccount(testUser(2), 0)
+	ctx := data.NewContext(ld)
+	store, err := createContract(t, ctx, testUser(2), 1, storeCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fail, err := createContract(t, ctx, testUser(2), 2, revertCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx, store, fail
+}
+
+func TestMultiCall(t *testing.T) {
+	ctx, store, _ := multiCallState(t)
+	tx := &MultiCall{
+		Base:  transaction.Base{Type_: multiCallType},
+		Seq_:  1,
+		From_: testUser(1),
+		Calls: []*ContractCall{
+			{Amount: amount.NewCoinAmount(1, 0), To: store},
+			{Amount: amount.NewCoinAmount(2, 0), To: store},
+		},
+	}
+	ret, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rets := ret.([][]byte); len(rets) != 2 {
+		t.Fatalf("got %d results, want 2", len(rets))
+	}
+	// the sequence is increased once for all the calls
+	if seq := ctx.Seq(testUser(1)); seq != 1 {
+		t.Fatalf("got sequence %d, want 1", seq)
+	}
+	if b := balanceOf(t, ctx, testUser(1)); !b.Equal(amount.NewCoinAmount(7, 0)) {
+		t.Fatalf("got the caller balance %v, want 7 coins", b)
+	}
+	if b := balanceOf(t, ctx, store); !b.Equal(amount.NewCoinAmount(3, 0)) {
+		t.Fatalf("got the contract balance %v, want 3 coins", b)
+	}
+}
+
+func TestMultiCallAtomic(t *testing.T) {
+	ctx, store, fail := multiCallState(t)
+	tx := &MultiCall{
+		Base:  transaction.Base{Type_: multiCallType},
+		Seq_:  1,
+		From_: testUser(1),
+		Calls: []*ContractCall{
+			{Amount: amount.NewCoinAmount(1, 0), To: store},
+			{Amount: amount.NewCoinAmount(0, 0), To: fail},
+		},
+	}
+	if _, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(1, 0)); err == nil {
+		t.Fatal("the MultiCall with the reverted call succeeded")
+	}
+	// the failure of the second call reverts the first call and the sequence
+	sd := &StateDB{Context: ctx}
+	if v := sd.GetState(store, hash.Hash256{}); v != (hash.Hash256{}) {
+		t.Fatalf("the storage of the first call is not reverted: %v", v)
+	}
+	if seq := ctx.Seq(testUser(1)); seq != 0 {
+		t.Fatalf("got sequence %d, want 0", seq)
+	}
+	if b := balanceOf(t, ctx, testUser(1)); !b.Equal(amount.NewCoinAmount(10, 0)) {
+		t.Fatalf("got the caller balance %v, want 10 coins", b)
+	}
+
+	tx.Calls = tx.Calls[:1]
+	if _, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(1, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if v := sd.GetState(store, hash.Hash256{}); v == (hash.Hash256{}) {
+		t.Fatal("the storage of the call is not stored")
+	}
+}
+
+func TestMultiCallByName(t *testing.T) {
+	ld := newTestLoader(t)
+	ld.addAccount(testUser(1), 10)
+	ld.addAccount(testUser(2), 0)
+	ctx := data.NewContext(ld)
+	store := createNamedContract(t, ctx, testUser(2), "store", storeCode)
+
+	tx := ld.newTx(t, "solidity.MultiCall").(*MultiCall)
+	tx.Seq_ = 1
+	tx.From_ = testUser(1)
+	tx.Calls = []*ContractCall{
+		{Amount: amount.NewCoinAmount(1, 0), To: store, ToName: "store"},
+	}
+	if err := ld.transactor.Validate(ld, tx, nil); err != ErrInvalidAccountName {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidAccountName)
+	}
+	tx.Calls[0].To = common.Address{}
+	if _, err := ctx.Transactor().Execute(ctx, tx, common.NewCoordinate(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if b := balanceOf(t, ctx, store); !b.Equal(amount.NewCoinAmount(1, 0)) {
+		t.Fatalf("got the contract balance %v, want 1 coin", b)
+	}
+
+	var buffer bytes.Buffer
+	if _, err := tx.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	read := &MultiCall{}
+	if _, err := read.ReadFrom(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if read.Calls[0].ToName != "store" {
+		t.Fatalf("got the name %q, want store", read.Calls[0].ToName)
+	}
+}
@@ -1,8 +1,12 @@
 package solidity
 
 import (
+	"encoding/binary"
+
 	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
 	"github.com/fletaio/core/amount"
+	"github.com/fletaio/core/data"
 	"github.com/fletaio/solidity/vm"
 )
 
@@ -18,3 +22,46 @@ func Transfer(db vm.StateDB, sender, recipient common.Address, amount *amount.Am
 		db.AddBalance(recipient, amount)
 	}
 }
+
+// contractAddressPrefix is the first byte of the address of the contract which is created by the CreateContract
+// The coordinate heights from 0xFE000000 are never reached by the chain, so the contract address cannot be
+// an address of an account which is created at a coordinate, and it is not in the range of vm.ReservedAddress
+const contractAddressPrefix = 0xFE
+
+// ContractRegistryAddress holds the addresses of the contracts which are created by the CreateContract
+// The entry of a created contract is not removed even after the contract is removed, so the address is not reused
+// It also holds the tombstones of the destroyed contracts
+var ContractRegistryAddress = vm.ReservedAddress(0x434F4E5452414354) // "CONTRACT"
+
+// ContractAddress returns the address of the contract which is created by the CreateContract of the creator and the salt
+// It doesn't depend on the block position, so the address can be computed before the submission
+// The address is the hash of the creator and the salt in the range of the contractAddressPrefix
+func ContractAddress(creator common.Address, salt uint64) common.Address {
+	bs := make([]byte, common.AddressSize+8)
+	copy(bs, creator[:])
+	binary.BigEndian.PutUint64(bs[common.AddressSize:], salt)
+	h := hash.Hash(bs)
+	var addr common.Address
+	addr[0] = contractAddressPrefix
+	copy(addr[1:], h[:])
+	return addr
+}
+
+// contractRegistryKey returns the key of the contract address in the ContractRegistryAddress
+func contractRegistryKey(addr common.Address) []byte {
+	k := hash.Hash(append([]byte("__CONTRACT__"), addr[:]...))
+	return k[:]
+}
+
+// destroyedKey returns the key of the tombstone of the destroyed contract address in the ContractRegistryAddress
+func destroyedKey(addr common.Address) []byte {
+	k := hash.Hash(append([]byte("__DESTROYED__"), addr[:]...))
+	return k[:]
+}
+
+// IsDestroyedAddress returns true when the contract of the address is destroyed by the SELFDESTRUCT
+// Only the legacy contracts are tombstoned, because their storage slots are not indexed and they are not deleted,
+// so the destroyed address cannot be used by a new account
+func IsDestroyedAddress(loader data.Loader, addr common.Address) bool {
+	return len(loader.AccountData(ContractRegistryAddress, destroyedKey(addr))) > 0
+}
@@ -1,6 +1,7 @@
 package solidity
 
 import (
+	"context"
 	"encoding/binary"
 	"log"
 
@@ -57,9 +58,10 @@ func (sd *ViewDB) GetCodeHash(addr common.Address) hash.Hash256 {
 	return sd.GetState(addr, KeywordCodeHash)
 }
 
-// GetCode returns the code of the address
+// GetCode returns the code of the address from the shared code store
+// It is read-only, so the code of the legacy contract is loaded from the account without the migration
 func (sd *ViewDB) GetCode(addr common.Address) []byte {
-	return sd.Loader.AccountData(addr, KeywordCode[:])
+	return loadCode(sd.Loader, addr)
 }
 
 // SetCode is not allowed
@@ -87,6 +89,18 @@ func (sd *ViewDB) GetState(addr common.Address, h hash.Hash256) hash.Hash256 {
 	return ret
 }
 
+// GetSlotCount returns the number of the storage slots of the address
+// It returns false for the legacy accounts which don't have the accounting marker, because their slots are not counted
+func (sd *ViewDB) GetSlotCount(addr common.Address) (uint64, bool) {
+	return readUint64(sd.Loader, addr, KeywordSlotCount), isAccounted(sd.Loader, addr)
+}
+
+// GetSlotSize returns the byte size of the storage slots of the address
+// It returns false for the legacy accounts which don't have the accounting marker, because their slots are not counted
+func (sd *ViewDB) GetSlotSize(addr common.Address) (uint64, bool) {
+	return readUint64(sd.Loader, addr, KeywordSlotSize), isAccounted(sd.Loader, addr)
+}
+
 // SetState is not allowed
 func (sd *ViewDB) SetState(addr common.Address, h hash.Hash256, v hash.Hash256) {
 	panic(ErrNotAllowed)
@@ -104,6 +118,33 @@ func (sd *ViewDB) HasSuicided(addr common.Address) bool {
 	return len(bs) > 0 && bs[0] == 1
 }
 
+// PayRent doesn't charge the rent and returns an error when the contract of the address is dormant
+func (sd *ViewDB) PayRent(addr common.Address) error {
+	if rentPolicy == nil {
+		return nil
+	}
+	acc, err := sd.Loader.Account(addr)
+	if err != nil {
+		return err
+	}
+	if _, is := acc.(*ContractAccount); !is {
+		return nil
+	}
+	size, accounted := sd.GetSlotSize(addr)
+	if !accounted {
+		return nil
+	}
+	height := sd.Loader.TargetHeight()
+	rent, err := rentPolicy.rentOf(size, rentHeight(sd.Loader, addr, height), height)
+	if err != nil {
+		return err
+	}
+	if acc.Balance().Less(rent) {
+		return ErrDormantContract
+	}
+	return nil
+}
+
 // Exist checks that the account of the address is exist or not
 func (sd *ViewDB) Exist(addr common.Address) bool {
 	if exist, err := sd.Loader.IsExistAccount(addr); err != nil {
@@ -139,3 +180,26 @@ func (sd *ViewDB) Snapshot() int {
 func (sd *ViewDB) AddLog(l *vm.Log) {
 	log.Println("AddLog", l)
 }
+
+// ViewCall executes the input on the contract of the address without any modification of the loader
+// It is aborted with ErrExecutionTimeout when the deadline of the gctx is exceeded
+func ViewCall(gctx context.Context, loader data.Loader, from common.Address, to common.Address, input []byte) (ret []byte, rerr error) {
+	defer func() {
+		if e := recover(); e != nil {
+			if err, is := e.(error); is {
+				rerr = err
+			} else {
+				rerr = ErrVirtualMachinePanic
+			}
+		}
+	}()
+
+	evm := newEVM(execConfig, loader, &ViewDB{Loader: loader}, loader.TargetHeight(), from, nil)
+	if err := runEVM(gctx, evm, func() (err error) {
+		ret, err = evm.StaticCall(vm.AccountRef(from), to, input)
+		return
+	}); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
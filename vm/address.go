@@ -1,12 +1,34 @@
 package vm
 
 import (
-	"math/big"
+	"encoding/binary"
 
 	"github.com/fletaio/common"
 	"github.com/fletaio/common/hash"
 )
 
+// ReservedAddress returns the address of the reserved coordinate (0xFFFFFFFF, 0xFFFF) with the nonce
+// The height of the reserved coordinate is never reached by the chain, so the address cannot be
+// an address of an account which is created at a coordinate
+func ReservedAddress(nonce uint64) common.Address {
+	var addr common.Address
+	for i := 0; i < common.AddressSize-8; i++ {
+		addr[i] = 0xFF
+	}
+	binary.BigEndian.PutUint64(addr[common.AddressSize-8:], nonce)
+	return addr
+}
+
+// IsReservedAddress returns true when the address is an address of the reserved coordinate
+func IsReservedAddress(addr common.Address) bool {
+	for i := 0; i < common.AddressSize-8; i++ {
+		if addr[i] != 0xFF {
+			return false
+		}
+	}
+	return true
+}
+
 // BytesToAddress get a address from the bytes
 func BytesToAddress(bs []byte) common.Address {
 	var addr common.Address
@@ -17,6 +39,15 @@ func BytesToAddress(bs []byte) common.Address {
 	return addr
 }
 
+// WordToAddress get a address from the low AddressSize bytes of the word
+// The address is right-aligned in the word like the AddressToWord, so the leading zero bytes of the address are kept
+func WordToAddress(w *Word) common.Address {
+	var addr common.Address
+	bs := w.Bytes32()
+	copy(addr[:], bs[32-common.AddressSize:])
+	return addr
+}
+
 // BytesToHash get a hash from the bytes
 func BytesToHash(bs []byte) hash.Hash256 {
 	var h hash.Hash256
@@ -27,16 +58,12 @@ func BytesToHash(bs []byte) hash.Hash256 {
 	return h
 }
 
-// AddressToBig get a big from the bytes
-func AddressToBig(addr common.Address) *big.Int {
-	v := new(big.Int)
-	v.SetBytes(addr[:])
-	return v
+// AddressToWord get a word from the address
+func AddressToWord(addr common.Address) *Word {
+	return new(Word).SetBytes(addr[:])
 }
 
-// HashToBig get a big from the hash
-func HashToBig(h hash.Hash256) *big.Int {
-	v := new(big.Int)
-	v.SetBytes(h[:])
-	return v
+// HashToWord get a word from the hash
+func HashToWord(h hash.Hash256) *Word {
+	return new(Word).SetBytes(h[:])
 }
@@ -17,8 +17,6 @@
 package vm
 
 import (
-	"math/big"
-
 	"github.com/fletaio/common/hash"
 )
 
@@ -28,7 +26,7 @@ import (
 type destinations map[hash.Hash256]bitvec
 
 // has checks whether code has a JUMPDEST at dest.
-func (d destinations) has(codehash hash.Hash256, code []byte, dest *big.Int) bool {
+func (d destinations) has(codehash hash.Hash256, code []byte, dest *Word) bool {
 	// PC cannot go beyond len(code) and certainly can't be bigger than 63bits.
 	// Don't bother checking for JUMPDEST in that case.
 	udest := dest.Uint64()
@@ -38,7 +36,7 @@ func (d destinations) has(codehash hash.Hash256, code []byte, dest *big.Int) boo
 
 	m, analysed := d[codehash]
 	if !analysed {
-		m = codeBitmap(code)
+		m = DefaultAnalysisCache.analyse(codehash, code)
 		d[codehash] = m
 	}
 	return OpCode(code[udest]) == JUMPDEST && m.codeSegment(udest)
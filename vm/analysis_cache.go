@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fletaio/common/hash"
+)
+
+// DefaultAnalysisCache is the JUMPDEST analysis cache shared across EVM instances
+var DefaultAnalysisCache = NewAnalysisCache(1024)
+
+// AnalysisCache is a bounded LRU cache of JUMPDEST analysis bitmaps keyed by code hash.
+// It is safe for concurrent use and the cached bitmaps are never modified.
+type AnalysisCache struct {
+	hits   uint64 // accessed atomically, kept first for the 64-bit alignment
+	misses uint64
+	mu     sync.Mutex
+	size   int
+	ll     *list.List
+	items  map[hash.Hash256]*list.Element
+}
+
+type analysisEntry struct {
+	codehash hash.Hash256
+	bits     bitvec
+}
+
+// NewAnalysisCache returns a AnalysisCache which keeps up to size bitmaps
+func NewAnalysisCache(size int) *AnalysisCache {
+	return &AnalysisCache{
+		size:  size,
+		ll:    list.New(),
+		items: map[hash.Hash256]*list.Element{},
+	}
+}
+
+// analyse returns the cached bitmap of the code or analyses and caches it
+func (c *AnalysisCache) analyse(codehash hash.Hash256, code []byte) bitvec {
+	c.mu.Lock()
+	if e, has := c.items[codehash]; has {
+		c.ll.MoveToFront(e)
+		c.mu.Unlock()
+		atomic.AddUint64(&c.hits, 1)
+		return e.Value.(*analysisEntry).bits
+	}
+	c.mu.Unlock()
+	atomic.AddUint64(&c.misses, 1)
+
+	bits := codeBitmap(code)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, has := c.items[codehash]; has {
+		c.ll.MoveToFront(e)
+		return bits
+	}
+	c.items[codehash] = c.ll.PushFront(&analysisEntry{codehash: codehash, bits: bits})
+	for c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*analysisEntry).codehash)
+	}
+	return bits
+}
+
+// Len returns the number of the cached bitmaps
+func (c *AnalysisCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Stats returns the hit and miss counts of the cache
+func (c *AnalysisCache) Stats() (hits uint64, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
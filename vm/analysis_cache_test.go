@@ -0,0 +1,91 @@
+package vm
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+)
+
+// largeContract returns the code which jumps over the size bytes of PUSH32 data to the end of it
+func largeContract(size int) []byte {
+	code := []byte{byte(PUSH3), 0, 0, 0, byte(JUMP)}
+	for len(code) < size {
+		code = append(code, byte(PUSH32))
+		code = append(code, make([]byte, 32)...)
+	}
+	dest := len(code)
+	code[1], code[2], code[3] = byte(dest>>16), byte(dest>>8), byte(dest)
+	return append(code, byte(JUMPDEST), byte(STOP))
+}
+
+func TestAnalysisCacheConcurrent(t *testing.T) {
+	const (
+		workers = 16
+		rounds  = 200
+	)
+	codes := make([][]byte, 8)
+	for i := range codes {
+		codes[i] = largeContract(1024 * (i + 1))
+	}
+	c := NewAnalysisCache(4)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				code := codes[(w+r)%len(codes)]
+				bits := c.analyse(hash.Hash(code), code)
+				if !bytes.Equal(bits, codeBitmap(code)) {
+					t.Errorf("bitmap mismatch of the code %d", (w+r)%len(codes))
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	hits, misses := c.Stats()
+	if hits+misses != workers*rounds {
+		t.Fatalf("hits %d + misses %d != calls %d", hits, misses, workers*rounds)
+	}
+	if misses < uint64(len(codes)) {
+		t.Fatalf("misses %d < distinct codes %d", misses, len(codes))
+	}
+	if c.Len() > 4 {
+		t.Fatalf("cache size %d exceeds the bound 4", c.Len())
+	}
+}
+
+func benchmarkCallLargeContract(b *testing.B, cache *AnalysisCache) {
+	prev := DefaultAnalysisCache
+	DefaultAnalysisCache = cache
+	defer func() { DefaultAnalysisCache = prev }()
+
+	st := newTestState()
+	caller, addr := testAddress(1), testAddress(2)
+	st.CreateAccount(caller, "")
+	st.deploy(addr, largeContract(MaxCodeSize-64))
+	zero := amount.NewCoinAmount(0, 0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// each transaction has its own EVM, so only the shared cache keeps the analysis
+		evm := newTestEVM(st, Config{})
+		if _, err := evm.Call(AccountRef(caller), addr, nil, zero); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCallLargeContract(b *testing.B) {
+	b.Run("cached", func(b *testing.B) {
+		benchmarkCallLargeContract(b, NewAnalysisCache(1024))
+	})
+	b.Run("uncached", func(b *testing.B) {
+		benchmarkCallLargeContract(b, NewAnalysisCache(0))
+	})
+}
@@ -0,0 +1,256 @@
+package vm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// asmItem is an instruction or a data of the assembly
+type asmItem struct {
+	line   int
+	op     OpCode
+	isData bool
+	data   []byte
+	size   int    // size of the push data
+	label  string // label of the push data
+	minus  string // label which is subtracted from the label
+}
+
+// Assemble returns the bytecode of the assembly source
+//
+// The source has one item per line and the text after ';' is a comment:
+//
+//	name:             defines the label of the next offset
+//	PUSH1 0x80        push with the sized immediate (hex or decimal)
+//	PUSH 0x1234       push with the auto sized immediate
+//	PUSH name         push the offset of the label in 2 bytes
+//	PUSH end-start    push the difference of the offsets of the labels in 2 bytes
+//	JUMP name         PUSH name and JUMP (also JUMPI)
+//	INVALID(0xfe)     the raw byte of an undefined opcode
+//	.data 0x1234      raw bytes
+//
+// The leading offsets of the disassembler listing like "00001d:" are ignored,
+// so the listing of the disassembler is assembled to the same bytecode
+func Assemble(src string) ([]byte, error) {
+	items := []*asmItem{}
+	labels := map[string]int{}
+	offset := 0
+	for i, line := range strings.Split(src, "\n") {
+		lineNo := i + 1
+		if idx := strings.Index(line, ";"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		for len(fields) > 0 && strings.HasSuffix(fields[0], ":") {
+			name := strings.TrimSuffix(fields[0], ":")
+			fields = fields[1:]
+			if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+				// offset of the disassembler listing
+				continue
+			}
+			if !isLabelName(name) {
+				return nil, fmt.Errorf("line %d: invalid label %s", lineNo, name)
+			}
+			if _, has := labels[name]; has {
+				return nil, fmt.Errorf("line %d: duplicated label %s", lineNo, name)
+			}
+			labels[name] = offset
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		parsed, err := parseAsmItems(lineNo, fields)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range parsed {
+			items = append(items, it)
+			if it.isData {
+				offset += len(it.data)
+			} else {
+				offset += 1 + it.size
+			}
+		}
+	}
+
+	code := make([]byte, 0, offset)
+	for _, it := range items {
+		if it.isData {
+			code = append(code, it.data...)
+			continue
+		}
+		code = append(code, byte(it.op))
+		if len(it.label) > 0 {
+			v, has := labels[it.label]
+			if !has {
+				return nil, fmt.Errorf("line %d: unknown label %s", it.line, it.label)
+			}
+			if len(it.minus) > 0 {
+				m, has := labels[it.minus]
+				if !has {
+					return nil, fmt.Errorf("line %d: unknown label %s", it.line, it.minus)
+				}
+				if v < m {
+					return nil, fmt.Errorf("line %d: negative value %s-%s", it.line, it.label, it.minus)
+				}
+				v -= m
+			}
+			bs := new(big.Int).SetInt64(int64(v)).Bytes()
+			if len(bs) > it.size {
+				return nil, fmt.Errorf("line %d: label %s exceeds PUSH%d", it.line, it.label, it.size)
+			}
+			it.data = LeftPadBytes(bs, it.size)
+		}
+		code = append(code, it.data...)
+	}
+	return code, nil
+}
+
+// parseAsmItems parses the fields of the line
+func parseAsmItems(lineNo int, fields []string) ([]*asmItem, error) {
+	name := strings.ToUpper(fields[0])
+	args := fields[1:]
+	switch {
+	case name == ".DATA":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("line %d: .data requires a hex", lineNo)
+		}
+		bs, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		return []*asmItem{{line: lineNo, isData: true, data: bs}}, nil
+	case strings.HasPrefix(name, "INVALID(") && strings.HasSuffix(name, ")"):
+		bs, err := hex.DecodeString(strings.TrimPrefix(name[len("INVALID("):len(name)-1], "0X"))
+		if err != nil || len(bs) != 1 {
+			return nil, fmt.Errorf("line %d: invalid opcode %s", lineNo, fields[0])
+		}
+		return []*asmItem{{line: lineNo, isData: true, data: bs}}, nil
+	case name == "INVALID":
+		return []*asmItem{{line: lineNo, isData: true, data: []byte{0xfe}}}, nil
+	case strings.HasPrefix(name, "PUSH"):
+		it, err := parsePush(lineNo, name, args)
+		if err != nil {
+			return nil, err
+		}
+		return []*asmItem{it}, nil
+	}
+
+	op := StringToOp(name)
+	if op.String() != name || op.IsPush() {
+		return nil, fmt.Errorf("line %d: unknown opcode %s", lineNo, fields[0])
+	}
+	if len(args) == 0 {
+		return []*asmItem{{line: lineNo, op: op}}, nil
+	}
+	if (op == JUMP || op == JUMPI) && len(args) == 1 {
+		push, err := parsePush(lineNo, "PUSH", args)
+		if err != nil {
+			return nil, err
+		}
+		return []*asmItem{push, {line: lineNo, op: op}}, nil
+	}
+	return nil, fmt.Errorf("line %d: %s doesn't take arguments", lineNo, name)
+}
+
+// parsePush parses the push instruction
+// The immediate of the push data without the size is auto sized, and the label is 2 bytes
+func parsePush(lineNo int, name string, args []string) (*asmItem, error) {
+	size := 0
+	if name != "PUSH" {
+		op := StringToOp(name)
+		if !op.IsPush() {
+			return nil, fmt.Errorf("line %d: unknown opcode %s", lineNo, name)
+		}
+		size = int(op-PUSH1) + 1
+	}
+	truncated := false
+	if len(args) == 2 && args[1] == "(truncated)" {
+		truncated = true
+		args = args[:1]
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("line %d: %s requires an immediate", lineNo, name)
+	}
+	arg := args[0]
+
+	if isLabelName(strings.SplitN(arg, "-", 2)[0]) {
+		it := &asmItem{line: lineNo, size: size}
+		parts := strings.SplitN(arg, "-", 2)
+		it.label = parts[0]
+		if len(parts) == 2 {
+			if !isLabelName(parts[1]) {
+				return nil, fmt.Errorf("line %d: invalid label %s", lineNo, parts[1])
+			}
+			it.minus = parts[1]
+		}
+		if it.size == 0 {
+			it.size = 2
+		}
+		it.op = PUSH1 + OpCode(it.size-1)
+		return it, nil
+	}
+
+	var bs []byte
+	if strings.HasPrefix(arg, "0x") || strings.HasPrefix(arg, "0X") {
+		hexStr := arg[2:]
+		if len(hexStr)%2 == 1 {
+			hexStr = "0" + hexStr
+		}
+		v, err := hex.DecodeString(hexStr)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		bs = v
+	} else {
+		v, ok := new(big.Int).SetString(arg, 10)
+		if !ok || v.Sign() < 0 {
+			return nil, fmt.Errorf("line %d: invalid immediate %s", lineNo, arg)
+		}
+		bs = v.Bytes()
+	}
+	if truncated {
+		if size == 0 || len(bs) >= size {
+			return nil, fmt.Errorf("line %d: invalid truncated immediate %s", lineNo, arg)
+		}
+		// the truncated push is the end of the code, so the data is emitted as it is
+		return &asmItem{line: lineNo, op: PUSH1 + OpCode(size-1), size: len(bs), data: bs}, nil
+	}
+	if size == 0 {
+		// the size of the hex digits or the minimal size of the decimal
+		size = len(bs)
+		if size == 0 {
+			bs = []byte{0}
+			size = 1
+		}
+	}
+	if size > 32 {
+		return nil, fmt.Errorf("line %d: immediate %s exceeds 32 bytes", lineNo, arg)
+	}
+	trimmed := bs
+	for len(trimmed) > size && trimmed[0] == 0 {
+		trimmed = trimmed[1:]
+	}
+	if len(trimmed) > size {
+		return nil, fmt.Errorf("line %d: immediate %s exceeds PUSH%d", lineNo, arg, size)
+	}
+	return &asmItem{line: lineNo, op: PUSH1 + OpCode(size-1), size: size, data: LeftPadBytes(trimmed, size)}, nil
+}
+
+// isLabelName returns true when the name starts with a letter or '_' and consists of letters, digits and '_'
+func isLabelName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package vm
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// roundTrip checks that the listing of the disassembly is assembled to the same code
+func roundTrip(t *testing.T, code []byte) *Disassembly {
+	t.Helper()
+	d := DisassembleContract(code, nil)
+	listing := d.String()
+	got, err := Assemble(listing)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, listing)
+	}
+	if !bytes.Equal(got, code) {
+		t.Fatalf("got %x, want %x\n%s", got, code, listing)
+	}
+	return d
+}
+
+func TestAssembleRoundTrip(t *testing.T) {
+	roundTrip(t, mustAssemble(t, `
+		PUSH1 0x80
+		PUSH1 0x40
+		MSTORE
+		CALLDATASIZE
+		JUMPI end
+		INVALID(0x0c)
+		INVALID
+		end:
+		JUMPDEST
+		PUSH32 0x0102030405060708091011121314151617181920212223242526272829303132
+		STOP
+	`))
+	roundTrip(t, nil)
+}
+
+func TestAssembleTruncatedPush(t *testing.T) {
+	for _, code := range [][]byte{
+		{byte(PUSH4), 0x01, 0x02},
+		{byte(PUSH4), 0x00, 0x00, 0x01},
+		{byte(PUSH1), 0x01, byte(PUSH32)},
+		{byte(PUSH2)},
+	} {
+		d := roundTrip(t, code)
+		last := d.Runtime[len(d.Runtime)-1]
+		if !last.Truncated {
+			t.Fatalf("the push of %x is not truncated", code)
+		}
+	}
+}
+
+func TestAssembleContractRoundTrip(t *testing.T) {
+	runtime := mustAssemble(t, `
+		PUSH1 0
+		CALLDATALOAD
+		PUSH1 0
+		SSTORE
+		STOP
+	`)
+	metadata := append([]byte{0xa1, 0x65, 'b', 'z', 'z', 'r', '0', 0x58, 0x20}, bytes.Repeat([]byte{0xab}, 32)...)
+	metadata = append(metadata, 0x00, byte(len(metadata)))
+	body := append(append([]byte{}, runtime...), metadata...)
+	args := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	constructor := mustAssemble(t, `
+		PUSH2 0x0000
+		DUP1
+		PUSH start
+		PUSH1 0
+		CODECOPY
+		PUSH1 0
+		RETURN
+		start:
+	`)
+	code := append(append(append([]byte{}, constructor...), body...), args...)
+	// patch the size of the runtime code which the constructor copies
+	code[1], code[2] = byte(len(body)>>8), byte(len(body))
+
+	d := roundTrip(t, code)
+	if d.Constructor == nil || d.RuntimeOffset != uint64(len(constructor)) {
+		t.Fatalf("the constructor is not separated at %d: %d", len(constructor), d.RuntimeOffset)
+	}
+	if !bytes.Equal(d.Metadata, metadata) || d.MetadataOffset != uint64(len(runtime)) {
+		t.Fatalf("the metadata is not separated: %x at %d", d.Metadata, d.MetadataOffset)
+	}
+	if !bytes.Equal(d.ConstructorArgs, args) {
+		t.Fatalf("got the constructor arguments %x, want %x", d.ConstructorArgs, args)
+	}
+	if len(d.Runtime) != 5 || d.Runtime[4].Op != STOP {
+		t.Fatalf("the runtime code is not separated from the metadata:\n%s", d.String())
+	}
+	for _, s := range []string{"; constructor\n", "; runtime at ", "; metadata at ", "; constructor arguments\n"} {
+		if !strings.Contains(d.String(), s) {
+			t.Fatalf("the listing doesn't have %q:\n%s", s, d.String())
+		}
+	}
+}
+
+func TestAssembleRandomRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		code := make([]byte, r.Intn(128))
+		r.Read(code)
+		roundTrip(t, code)
+	}
+}
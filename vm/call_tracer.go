@@ -0,0 +1,157 @@
+package vm
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/solidity/vm/hexutil"
+)
+
+// revertSelector is the selector of Error(string) which is used by the revert reason
+var revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// CallFrame is a frame of the call tree which is built by the CallTracer
+type CallFrame struct {
+	Type         string         `json:"type"`
+	From         common.Address `json:"from"`
+	To           common.Address `json:"to"`
+	Value        *amount.Amount `json:"value,omitempty"`
+	Input        hexutil.Bytes  `json:"input"`
+	Output       hexutil.Bytes  `json:"output,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	RevertReason string         `json:"revertReason,omitempty"`
+	Calls        []*CallFrame   `json:"calls,omitempty"`
+}
+
+// CallTracer is a Tracer which builds the call tree of the execution
+// including the internal CALL, CALLCODE, DELEGATECALL, STATICCALL and CREATE frames
+type CallTracer struct {
+	root  *CallFrame
+	stack []*CallFrame
+}
+
+// NewCallTracer returns a CallTracer
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+// CaptureStart starts the root frame
+func (t *CallTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, value *amount.Amount) error {
+	typ := CALL
+	if create {
+		typ = CREATE
+	}
+	t.root = newCallFrame(typ, from, to, input, value)
+	t.stack = []*CallFrame{t.root}
+	return nil
+}
+
+// CaptureState doesn't work
+func (t *CallTracer) CaptureState(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureFault doesn't work
+func (t *CallTracer) CaptureFault(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd ends the root frame
+func (t *CallTracer) CaptureEnd(output []byte, d time.Duration, err error) error {
+	if t.root != nil {
+		t.root.finish(output, err)
+	}
+	t.stack = nil
+	return nil
+}
+
+// CaptureEnter starts the internal frame as a child of the current frame
+func (t *CallTracer) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, value *amount.Amount) error {
+	if len(t.stack) == 0 {
+		return nil
+	}
+	frame := newCallFrame(typ, from, to, input, value)
+	parent := t.stack[len(t.stack)-1]
+	parent.Calls = append(parent.Calls, frame)
+	t.stack = append(t.stack, frame)
+	return nil
+}
+
+// CaptureExit ends the current internal frame
+func (t *CallTracer) CaptureExit(output []byte, err error) error {
+	if len(t.stack) <= 1 {
+		return nil
+	}
+	t.stack[len(t.stack)-1].finish(output, err)
+	t.stack = t.stack[:len(t.stack)-1]
+	return nil
+}
+
+// Result returns the root frame of the call tree
+func (t *CallTracer) Result() *CallFrame {
+	return t.root
+}
+
+// MarshalJSON is a marshaler function
+func (t *CallTracer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.root)
+}
+
+func newCallFrame(typ OpCode, from common.Address, to common.Address, input []byte, value *amount.Amount) *CallFrame {
+	frame := &CallFrame{
+		Type:  typ.String(),
+		From:  from,
+		To:    to,
+		Input: CopyBytes(input),
+	}
+	if value != nil {
+		frame.Value = value.Clone()
+	}
+	return frame
+}
+
+func (f *CallFrame) finish(output []byte, err error) {
+	f.Output = CopyBytes(output)
+	if err != nil {
+		f.Error = err.Error()
+		if err == errExecutionReverted {
+			f.RevertReason = unpackRevertReason(output)
+		} else {
+			f.Output = nil
+		}
+	}
+}
+
+// unpackRevertReason returns the message of the Error(string) revert data
+func unpackRevertReason(data []byte) string {
+	if len(data) < 4+64 || string(data[:4]) != string(revertSelector) {
+		return ""
+	}
+	data = data[4:]
+	offset, ok := wordToInt(data[:32])
+	if !ok || offset+32 > len(data) {
+		return ""
+	}
+	size, ok := wordToInt(data[offset : offset+32])
+	if !ok || offset+32+size > len(data) {
+		return ""
+	}
+	return string(data[offset+32 : offset+32+size])
+}
+
+// wordToInt returns the int of the 32 bytes big endian word when it is small enough
+func wordToInt(word []byte) (int, bool) {
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	v := binary.BigEndian.Uint64(word[24:])
+	if v > 1<<31 {
+		return 0, false
+	}
+	return int(v), true
+}
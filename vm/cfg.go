@@ -0,0 +1,267 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// EdgeType is the type of the edge of the control flow graph
+type EdgeType string
+
+// edge types
+const (
+	EdgeJump        = EdgeType("jump")        // JUMP to the static target
+	EdgeBranch      = EdgeType("branch")      // JUMPI to the static target
+	EdgeFallthrough = EdgeType("fallthrough") // the next block
+)
+
+// Edge is an edge of the control flow graph
+type Edge struct {
+	Target uint64   `json:"target"`
+	Type   EdgeType `json:"type"`
+}
+
+// BasicBlock is a block of the instructions which are executed in sequence
+type BasicBlock struct {
+	Start        uint64
+	End          uint64 // offset of the last instruction
+	Instructions []Instruction
+	Successors   []Edge
+	DynamicJump  bool // the block ends with a jump whose target is not static
+	InvalidJump  bool // the block ends with a jump to the static target which is not a JUMPDEST
+	Reachable    bool
+}
+
+// MarshalJSON is a marshaler function
+func (b *BasicBlock) MarshalJSON() ([]byte, error) {
+	ins := make([]string, 0, len(b.Instructions))
+	for i := range b.Instructions {
+		ins = append(ins, fmt.Sprintf("%06x: %s", b.Instructions[i].Offset, b.Instructions[i].String()))
+	}
+	succ := b.Successors
+	if succ == nil {
+		succ = []Edge{}
+	}
+	return json.Marshal(&struct {
+		Start        uint64   `json:"start"`
+		End          uint64   `json:"end"`
+		Instructions []string `json:"instructions"`
+		Successors   []Edge   `json:"successors"`
+		DynamicJump  bool     `json:"dynamicJump,omitempty"`
+		InvalidJump  bool     `json:"invalidJump,omitempty"`
+		Reachable    bool     `json:"reachable"`
+	}{
+		Start:        b.Start,
+		End:          b.End,
+		Instructions: ins,
+		Successors:   succ,
+		DynamicJump:  b.DynamicJump,
+		InvalidJump:  b.InvalidJump,
+		Reachable:    b.Reachable,
+	})
+}
+
+// ControlFlowGraph is the static control flow graph of the code
+type ControlFlowGraph struct {
+	Blocks []*BasicBlock
+	blocks map[uint64]*BasicBlock
+}
+
+// BuildControlFlowGraph splits the code into the basic blocks and connects them by the static jumps
+// The code is disassembled by the rules (nil means the rules of the LatestFork), so the invalid opcodes end the blocks
+// The jump destinations are validated by the JUMPDEST analysis of the interpreter
+//
+// A block is reachable when it is reached from the entry by the static edges.
+// When a reachable block has a dynamic jump, the blocks which start with a JUMPDEST
+// are also considered as reachable because they can be the target of it
+func BuildControlFlowGraph(code []byte, rules *Rules) *ControlFlowGraph {
+	bitmap := codeBitmap(code)
+	isJumpDest := func(dest *Word) bool {
+		if !dest.IsUint64() || dest.Uint64() >= uint64(len(code)) {
+			return false
+		}
+		pos := dest.Uint64()
+		return OpCode(code[pos]) == JUMPDEST && bitmap.codeSegment(pos)
+	}
+
+	g := &ControlFlowGraph{
+		blocks: map[uint64]*BasicBlock{},
+	}
+	var block *BasicBlock
+	for _, in := range Disassemble(code, rules) {
+		if block != nil && in.JumpDest {
+			g.addBlock(block)
+			block = nil
+		}
+		if block == nil {
+			block = &BasicBlock{Start: in.Offset}
+		}
+		block.Instructions = append(block.Instructions, in)
+		block.End = in.Offset
+		if in.Op == JUMP || in.Op == JUMPI || isTerminator(&in) {
+			g.addBlock(block)
+			block = nil
+		}
+	}
+	if block != nil {
+		g.addBlock(block)
+	}
+
+	for i, b := range g.Blocks {
+		last := &b.Instructions[len(b.Instructions)-1]
+		switch {
+		case last.Op == JUMP || last.Op == JUMPI:
+			typ := EdgeJump
+			if last.Op == JUMPI {
+				typ = EdgeBranch
+			}
+			if len(b.Instructions) < 2 || !b.Instructions[len(b.Instructions)-2].Op.IsPush() {
+				b.DynamicJump = true
+			} else if dest := new(Word).SetBytes(b.Instructions[len(b.Instructions)-2].Data); isJumpDest(dest) {
+				b.Successors = append(b.Successors, Edge{Target: dest.Uint64(), Type: typ})
+			} else {
+				b.InvalidJump = true
+			}
+			if last.Op == JUMPI && i+1 < len(g.Blocks) {
+				b.Successors = append(b.Successors, Edge{Target: g.Blocks[i+1].Start, Type: EdgeFallthrough})
+			}
+		case isTerminator(last):
+		default:
+			if i+1 < len(g.Blocks) {
+				b.Successors = append(b.Successors, Edge{Target: g.Blocks[i+1].Start, Type: EdgeFallthrough})
+			}
+		}
+	}
+	g.markReachable()
+	return g
+}
+
+// isTerminator returns true when the instruction ends the execution
+func isTerminator(in *Instruction) bool {
+	switch in.Op {
+	case STOP, RETURN, REVERT, SELFDESTRUCT:
+		return true
+	}
+	return !in.Valid
+}
+
+func (g *ControlFlowGraph) addBlock(b *BasicBlock) {
+	g.Blocks = append(g.Blocks, b)
+	g.blocks[b.Start] = b
+}
+
+func (g *ControlFlowGraph) markReachable() {
+	if len(g.Blocks) == 0 {
+		return
+	}
+	dynamic := false
+	queue := []*BasicBlock{g.Blocks[0]}
+	g.Blocks[0].Reachable = true
+	visit := func(b *BasicBlock) {
+		if b != nil && !b.Reachable {
+			b.Reachable = true
+			queue = append(queue, b)
+		}
+	}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		for _, e := range b.Successors {
+			visit(g.blocks[e.Target])
+		}
+		if b.DynamicJump && !dynamic {
+			dynamic = true
+			for _, t := range g.Blocks {
+				if t.Instructions[0].JumpDest {
+					visit(t)
+				}
+			}
+		}
+	}
+}
+
+// Block returns the block which starts at the offset or nil
+func (g *ControlFlowGraph) Block(offset uint64) *BasicBlock {
+	return g.blocks[offset]
+}
+
+// Unreachable returns the blocks which are not reachable from the entry
+func (g *ControlFlowGraph) Unreachable() []*BasicBlock {
+	list := []*BasicBlock{}
+	for _, b := range g.Blocks {
+		if !b.Reachable {
+			list = append(list, b)
+		}
+	}
+	return list
+}
+
+// DynamicJumps returns the blocks which end with a jump whose target is not static
+func (g *ControlFlowGraph) DynamicJumps() []*BasicBlock {
+	list := []*BasicBlock{}
+	for _, b := range g.Blocks {
+		if b.DynamicJump {
+			list = append(list, b)
+		}
+	}
+	return list
+}
+
+// MarshalJSON is a marshaler function
+func (g *ControlFlowGraph) MarshalJSON() ([]byte, error) {
+	offsets := func(list []*BasicBlock) []uint64 {
+		ret := make([]uint64, 0, len(list))
+		for _, b := range list {
+			ret = append(ret, b.Start)
+		}
+		return ret
+	}
+	blocks := g.Blocks
+	if blocks == nil {
+		blocks = []*BasicBlock{}
+	}
+	return json.Marshal(&struct {
+		Blocks       []*BasicBlock `json:"blocks"`
+		Unreachable  []uint64      `json:"unreachable"`
+		DynamicJumps []uint64      `json:"dynamicJumps"`
+	}{
+		Blocks:       blocks,
+		Unreachable:  offsets(g.Unreachable()),
+		DynamicJumps: offsets(g.DynamicJumps()),
+	})
+}
+
+// WriteDOT writes the graph in the DOT format of Graphviz
+func (g *ControlFlowGraph) WriteDOT(w io.Writer) (int64, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("digraph cfg {\n")
+	buffer.WriteString("\tnode [shape=box fontname=monospace];\n")
+	for _, b := range g.Blocks {
+		lines := make([]string, 0, len(b.Instructions))
+		for i := range b.Instructions {
+			lines = append(lines, fmt.Sprintf("%06x: %s", b.Instructions[i].Offset, b.Instructions[i].String()))
+		}
+		attrs := ""
+		switch {
+		case !b.Reachable:
+			attrs = " style=dashed color=gray"
+		case b.DynamicJump || b.InvalidJump:
+			attrs = " color=red"
+		}
+		fmt.Fprintf(&buffer, "\tb%d [label=\"%s\\l\"%s];\n", b.Start, strings.Join(lines, "\\l"), attrs)
+	}
+	for _, b := range g.Blocks {
+		succ := make([]Edge, len(b.Successors))
+		copy(succ, b.Successors)
+		sort.Slice(succ, func(i, j int) bool { return succ[i].Target < succ[j].Target })
+		for _, e := range succ {
+			fmt.Fprintf(&buffer, "\tb%d -> b%d [label=\"%s\"];\n", b.Start, e.Target, e.Type)
+		}
+	}
+	buffer.WriteString("}\n")
+	return buffer.WriteTo(w)
+}
@@ -0,0 +1,244 @@
+package vm
+
+import (
+	"encoding/json"
+
+	"github.com/fletaio/common"
+)
+
+// Fork is an upgrade of the virtual machine which changes the semantics of the execution
+// The later fork includes the changes of the earlier forks
+type Fork int
+
+// forks
+const (
+	Frontier Fork = iota
+	Homestead
+	Byzantium
+	Constantinople
+)
+
+// LatestFork is the latest fork which is supported by the virtual machine
+const LatestFork = Constantinople
+
+var forkNames = map[Fork]string{
+	Frontier:       "Frontier",
+	Homestead:      "Homestead",
+	Byzantium:      "Byzantium",
+	Constantinople: "Constantinople",
+}
+
+// String returns the name of the fork
+func (f Fork) String() string {
+	if name, has := forkNames[f]; has {
+		return name
+	}
+	return "Unknown"
+}
+
+// ParseFork returns the fork of the name
+func ParseFork(name string) (Fork, error) {
+	for f, n := range forkNames {
+		if n == name {
+			return f, nil
+		}
+	}
+	return 0, ErrUnknownFork
+}
+
+// MarshalJSON is a marshaler function
+func (f Fork) MarshalJSON() ([]byte, error) {
+	if _, has := forkNames[f]; !has {
+		return nil, ErrUnknownFork
+	}
+	return json.Marshal(f.String())
+}
+
+// UnmarshalJSON is a unmarshaler function
+func (f *Fork) UnmarshalJSON(bs []byte) error {
+	var name string
+	if err := json.Unmarshal(bs, &name); err != nil {
+		return err
+	}
+	v, err := ParseFork(name)
+	if err != nil {
+		return err
+	}
+	*f = v
+	return nil
+}
+
+// ForkActivation is the fork and the height from which the fork is activated
+type ForkActivation struct {
+	Fork   Fork   `json:"fork"`
+	Height uint32 `json:"height"`
+}
+
+// PrecompileActivation is the chain specific precompiled contract and the height from which it is activated
+type PrecompileActivation struct {
+	Address  common.Address
+	Contract PrecompiledContract
+	Height   uint32
+}
+
+// CodePolicyActivation is the validation policy of the deployed code and the height from which it is applied
+// The nil policy disables the validation from the height
+type CodePolicyActivation struct {
+	Height uint32
+	Policy *CodePolicy
+}
+
+// ChainConfig has the activation heights of the forks, the code policies and the chain specific precompiled contracts of the chain
+// A fork which is not listed is activated with the next listed fork
+type ChainConfig struct {
+	Forks        []ForkActivation       `json:"forks"`
+	CodePolicies []CodePolicyActivation `json:"-"`
+	Precompiles  []PrecompileActivation `json:"-"`
+}
+
+// DefaultChainConfig returns the config which activates the latest fork from the genesis
+// It doesn't have the code policy, so the deployed code is not validated
+func DefaultChainConfig() *ChainConfig {
+	return &ChainConfig{
+		Forks: []ForkActivation{
+			{Fork: LatestFork, Height: 0},
+		},
+	}
+}
+
+// Validate checks that the forks are supported and listed in the order of the forks and the heights
+// and the code policies are listed in the order of the heights
+func (c *ChainConfig) Validate() error {
+	for i, a := range c.Forks {
+		if _, has := forkNames[a.Fork]; !has {
+			return ErrUnknownFork
+		}
+		if i > 0 {
+			prev := c.Forks[i-1]
+			if a.Fork <= prev.Fork || a.Height < prev.Height {
+				return ErrInvalidForkOrder
+			}
+		}
+	}
+	for i, a := range c.CodePolicies {
+		if i > 0 && a.Height <= c.CodePolicies[i-1].Height {
+			return ErrInvalidCodePolicyOrder
+		}
+	}
+	addrs := map[common.Address]bool{}
+	for _, a := range c.Precompiles {
+		if !IsCustomPrecompileAddress(a.Address) {
+			return ErrReservedPrecompileAddress
+		}
+		if addrs[a.Address] {
+			return ErrExistPrecompile
+		}
+		addrs[a.Address] = true
+	}
+	return nil
+}
+
+// RegisterPrecompile adds the chain specific precompiled contract which is activated from the height
+// The address should be a CustomPrecompileAddress (the reserved address of the nonce 0x0001 to 0xffff)
+func (c *ChainConfig) RegisterPrecompile(addr common.Address, p PrecompiledContract, height uint32) error {
+	if !IsCustomPrecompileAddress(addr) {
+		return ErrReservedPrecompileAddress
+	}
+	for _, a := range c.Precompiles {
+		if a.Address == addr {
+			return ErrExistPrecompile
+		}
+	}
+	c.Precompiles = append(c.Precompiles, PrecompileActivation{
+		Address:  addr,
+		Contract: p,
+		Height:   height,
+	})
+	return nil
+}
+
+// PrecompileSet returns the precompiled contracts which are activated at the height
+// The returned set is a new set, so it is not shared with the other executions
+func (c *ChainConfig) PrecompileSet(height uint32) *PrecompileSet {
+	s := NewPrecompileSet(c.Fork(height))
+	for _, a := range c.Precompiles {
+		if a.Height <= height {
+			s.contracts[a.Address] = a.Contract
+		}
+	}
+	return s
+}
+
+// Fork returns the latest fork which is activated at the height
+func (c *ChainConfig) Fork(height uint32) Fork {
+	f := Frontier
+	for _, a := range c.Forks {
+		if a.Height <= height && a.Fork > f {
+			f = a.Fork
+		}
+	}
+	return f
+}
+
+// IsActivated returns true when the fork is activated at the height
+func (c *ChainConfig) IsActivated(f Fork, height uint32) bool {
+	return c.Fork(height) >= f
+}
+
+// CodePolicy returns the code policy which is applied at the height or nil
+func (c *ChainConfig) CodePolicy(height uint32) *CodePolicy {
+	var p *CodePolicy
+	for _, a := range c.CodePolicies {
+		if a.Height <= height {
+			p = a.Policy
+		}
+	}
+	return p
+}
+
+// Rules returns the rules of the fork which is activated at the height with the code policy of the height
+func (c *ChainConfig) Rules(height uint32) Rules {
+	r := NewRules(c.Fork(height))
+	r.CodePolicy = c.CodePolicy(height)
+	return r
+}
+
+// Rules are the semantics of the virtual machine by the fork
+type Rules struct {
+	Fork             Fork
+	IsHomestead      bool // DELEGATECALL
+	IsByzantium      bool // STATICCALL, RETURNDATA, REVERT, the code size limit and the name resolver precompile
+	IsConstantinople bool // SHL, SHR, SAR
+	// MaxCodeSize is the maximum size of the deployed code (0 means unlimited)
+	MaxCodeSize int
+	// CodePolicy validates the code which is deployed by the Create (nil means no validation)
+	CodePolicy *CodePolicy
+}
+
+// NewRules returns the rules of the fork
+func NewRules(f Fork) Rules {
+	r := Rules{
+		Fork:             f,
+		IsHomestead:      f >= Homestead,
+		IsByzantium:      f >= Byzantium,
+		IsConstantinople: f >= Constantinople,
+	}
+	if r.IsByzantium {
+		r.MaxCodeSize = MaxCodeSize
+	}
+	return r
+}
+
+// instructionSet returns the instruction set of the rules
+func (r *Rules) instructionSet() [256]operation {
+	switch {
+	case r.IsConstantinople:
+		return constantinopleInstructionSet
+	case r.IsByzantium:
+		return byzantiumInstructionSet
+	case r.IsHomestead:
+		return homesteadInstructionSet
+	default:
+		return frontierInstructionSet
+	}
+}
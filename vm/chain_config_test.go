@@ -0,0 +1,76 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/fletaio/core/amount"
+)
+
+func TestChainConfigCodePolicy(t *testing.T) {
+	strict := &CodePolicy{RejectEFPrefix: true}
+	c := DefaultChainConfig()
+	c.CodePolicies = []CodePolicyActivation{
+		{Height: 10, Policy: DefaultCodePolicy()},
+		{Height: 20, Policy: strict},
+		{Height: 30, Policy: nil},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		height uint32
+		policy *CodePolicy
+	}{
+		{0, nil},
+		{10, c.CodePolicies[0].Policy},
+		{19, c.CodePolicies[0].Policy},
+		{20, strict},
+		{30, nil},
+	}
+	for _, tt := range tests {
+		if rules := c.Rules(tt.height); rules.CodePolicy != tt.policy {
+			t.Fatalf("height %d: got the policy %v, want %v", tt.height, rules.CodePolicy, tt.policy)
+		}
+	}
+	if DefaultChainConfig().Rules(0).CodePolicy != nil {
+		t.Fatal("the default config has the code policy")
+	}
+
+	c.CodePolicies[1].Height = 10
+	if err := c.Validate(); err != ErrInvalidCodePolicyOrder {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidCodePolicyOrder)
+	}
+}
+
+func TestCodePolicyOfRules(t *testing.T) {
+	// the init code returns the runtime code 0xEF
+	initCode := mustAssemble(t, `
+		PUSH1 0xEF
+		PUSH1 0
+		MSTORE8
+		PUSH1 1
+		PUSH1 0
+		RETURN
+	`)
+	create := func(cfg Config) error {
+		st := newTestState()
+		caller := testAddress(1)
+		st.CreateAccount(caller, "")
+		evm := newTestEVM(st, cfg)
+		_, err := evm.Create(AccountRef(caller), testAddress(2), "", initCode, amount.NewCoinAmount(0, 0))
+		return err
+	}
+
+	rules := NewRules(LatestFork)
+	if err := create(Config{Rules: &rules}); err != nil {
+		t.Fatal(err)
+	}
+	rules.CodePolicy = DefaultCodePolicy()
+	if err, is := create(Config{Rules: &rules}).(*CodeValidationError); !is || err.Err != ErrInvalidCodePrefix {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidCodePrefix)
+	}
+	// the policy of the config overrides the policy of the rules
+	if err := create(Config{Rules: &rules, CodePolicy: &CodePolicy{}}); err != nil {
+		t.Fatal(err)
+	}
+}
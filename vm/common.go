@@ -45,13 +45,47 @@ func RightPadBytes(slice []byte, l int) []byte {
 	return padded
 }
 
-// calculates the memory size required for a step
-func calcMemSize(off, l *big.Int) *big.Int {
-	if l.Sign() == 0 {
-		return Big0
+// LeftPadBytes comes from github.com/ethereum/go-ethereum/common/bytes.go
+func LeftPadBytes(slice []byte, l int) []byte {
+	if l <= len(slice) {
+		return slice
+	}
+
+	padded := make([]byte, l)
+	copy(padded[l-len(slice):], slice)
+
+	return padded
+}
+
+// CopyBytes comes from github.com/ethereum/go-ethereum/common/bytes.go
+func CopyBytes(b []byte) (copiedBytes []byte) {
+	if b == nil {
+		return nil
+	}
+	copiedBytes = make([]byte, len(b))
+	copy(copiedBytes, b)
+
+	return
+}
+
+// calcMemSize64 calculates the memory size required for a step and reports whether it overflowed
+func calcMemSize64(off, l *Word) (uint64, bool) {
+	if !l.IsUint64() {
+		return 0, true
 	}
+	return calcMemSize64WithUint(off, l.Uint64())
+}
 
-	return new(big.Int).Add(off, l)
+// calcMemSize64WithUint calculates the memory size required for a step with the uint64 length
+func calcMemSize64WithUint(off *Word, length64 uint64) (uint64, bool) {
+	if length64 == 0 {
+		return 0, false
+	}
+	if !off.IsUint64() {
+		return 0, true
+	}
+	val := off.Uint64() + length64
+	return val, val < length64
 }
 
 // getData returns a slice from the data based on the start and size and pads
@@ -62,26 +96,20 @@ func getData(data []byte, start uint64, size uint64) []byte {
 		start = length
 	}
 	end := start + size
-	if end > length {
+	if end > length || end < start {
 		end = length
 	}
 	return RightPadBytes(data[start:end], int(size))
 }
 
-// getDataBig returns a slice from the data based on the start and size and pads
+// getDataWord returns a slice from the data based on the start and size and pads
 // up to size with zero's. This function is overflow safe.
-func getDataBig(data []byte, start *big.Int, size *big.Int) []byte {
-	dlen := big.NewInt(int64(len(data)))
-
-	s := math.BigMin(start, dlen)
-	e := math.BigMin(new(big.Int).Add(s, size), dlen)
-	return RightPadBytes(data[s.Uint64():e.Uint64()], int(size.Uint64()))
-}
-
-// bigUint64 returns the integer casted to a uint64 and returns whether it
-// overflowed in the process.
-func bigUint64(v *big.Int) (uint64, bool) {
-	return v.Uint64(), v.BitLen() > 64
+func getDataWord(data []byte, start *Word, size *Word) []byte {
+	s := uint64(len(data))
+	if start.IsUint64() && start.Uint64() < s {
+		s = start.Uint64()
+	}
+	return getData(data, s, size.Uint64())
 }
 
 // toWordSize returns the ceiled word size required for memory expansion.
@@ -28,14 +28,46 @@ type PrecompiledContract interface {
 	Run(input []byte) ([]byte, error) // Run runs the precompiled contract
 }
 
+// PrecompiledContractsHomestead contains the default set of pre-compiled Ethereum
+// contracts used in the Frontier and Homestead releases.
+// The EVM uses the copy of it made by the NewPrecompileSet, so the chain specific
+// precompiled contracts should be registered to the PrecompileSet or the ChainConfig
+var PrecompiledContractsHomestead = map[common.Address]PrecompiledContract{
+	builtinAddress(2): &sha256hash{},
+}
+
 // PrecompiledContractsByzantium contains the default set of pre-compiled Ethereum
 // contracts used in the Byzantium release.
 var PrecompiledContractsByzantium = map[common.Address]PrecompiledContract{
-	BytesToAddress([]byte{2}): &sha256hash{},
+	builtinAddress(2):    &sha256hash{},
+	builtinAddress(0x10): &nameResolver{},
+}
+
+// builtinAddress returns the address of the n-th builtin precompiled contract
+// It is the address of the word n, so the bytecode calls it by address(n) like the Ethereum
+func builtinAddress(n byte) common.Address {
+	var addr common.Address
+	addr[common.AddressSize-1] = n
+	return addr
+}
+
+// contextPrecompiledContract is the precompiled contract which uses the EVM context
+type contextPrecompiledContract interface {
+	PrecompiledContract
+	RunWithContext(ctx *Context, input []byte) ([]byte, error)
 }
 
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
-func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
+func RunPrecompiledContract(evm *EVM, p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
+	if sp, is := p.(StatefulPrecompiledContract); is {
+		// the depth is increased like the interpreter because the contract can call the EVM
+		evm.depth++
+		defer func() { evm.depth-- }()
+		return sp.RunStateful(newPrecompileEnv(evm, contract), input)
+	}
+	if cp, is := p.(contextPrecompiledContract); is {
+		return cp.RunWithContext(&evm.Context, input)
+	}
 	return p.Run(input)
 }
 
@@ -46,3 +78,25 @@ func (c *sha256hash) Run(input []byte) ([]byte, error) {
 	h := sha256.Sum256(input)
 	return h[:], nil
 }
+
+// nameResolver resolves the FLETA account name to the address.
+// The input is the account name and the output is the address which is left-padded to 32 bytes,
+// so the output is the word of the address which is called by the CALL.
+type nameResolver struct{}
+
+func (c *nameResolver) Run(input []byte) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+func (c *nameResolver) RunWithContext(ctx *Context, input []byte) ([]byte, error) {
+	if ctx.GetAddressByName == nil {
+		return nil, ErrNotSupported
+	}
+	addr, err := ctx.GetAddressByName(string(input))
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]byte, 32)
+	copy(ret[32-common.AddressSize:], addr[:])
+	return ret, nil
+}
@@ -0,0 +1,329 @@
+package vm
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/amount"
+)
+
+// DebugSession provides the commands to the Debugger and receives the output of it
+// The terminal REPL and the scripted sessions of the tests implement it
+type DebugSession interface {
+	// ReadCommand returns the next command line, io.EOF detaches the debugger
+	ReadCommand() (string, error)
+	// Output returns the writer of the debugger output
+	Output() io.Writer
+}
+
+// breakpoint pauses the execution at the pc of the contract or at the opcode
+type breakpoint struct {
+	addr  common.Address
+	pc    uint64
+	hasPC bool
+	op    OpCode
+	isOp  bool
+}
+
+// hit returns true when the execution is at the breakpoint
+func (b *breakpoint) hit(contract *Contract, pc uint64, op OpCode) bool {
+	if b.isOp {
+		return op == b.op
+	}
+	if contract.Address() != b.addr && (contract.CodeAddr == nil || *contract.CodeAddr != b.addr) {
+		return false
+	}
+	return !b.hasPC || pc == b.pc
+}
+
+// String returns the description of the breakpoint
+func (b *breakpoint) String() string {
+	if b.isOp {
+		return "op " + b.op.String()
+	}
+	if b.hasPC {
+		return fmt.Sprintf("%s pc=%d", b.addr.String(), b.pc)
+	}
+	return b.addr.String()
+}
+
+// Debugger is a Tracer which pauses the execution at the breakpoints or at each step in the stepping mode
+// While it is paused, it executes the commands of the session to inspect the state of the execution
+type Debugger struct {
+	session     DebugSession
+	out         io.Writer
+	breakpoints []*breakpoint
+	stepping    bool
+	detached    bool
+}
+
+// NewDebugger returns a Debugger which pauses at the first step of the execution
+func NewDebugger(session DebugSession) *Debugger {
+	return &Debugger{
+		session:  session,
+		out:      session.Output(),
+		stepping: true,
+	}
+}
+
+// CaptureStart prints the start of the execution
+func (d *Debugger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, value *amount.Amount) error {
+	if create {
+		fmt.Fprintf(d.out, "create %s from %s\n", to.String(), from.String())
+	} else {
+		fmt.Fprintf(d.out, "call %s from %s input=%x\n", to.String(), from.String(), input)
+	}
+	return nil
+}
+
+// CaptureState pauses the execution at the breakpoints or at each step in the stepping mode
+func (d *Debugger) CaptureState(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	if d.detached {
+		return nil
+	}
+	if !d.stepping {
+		hit := false
+		for _, b := range d.breakpoints {
+			if b.hit(contract, pc, op) {
+				fmt.Fprintf(d.out, "breakpoint %s\n", b.String())
+				hit = true
+				break
+			}
+		}
+		if !hit {
+			return nil
+		}
+	}
+	d.printWhere(pc, op, contract, depth)
+	for {
+		line, err := d.session.ReadCommand()
+		if err != nil {
+			d.detached = true
+			return nil
+		}
+		if resume := d.execute(strings.Fields(line), env, pc, op, memory, stack, contract, depth); resume {
+			return nil
+		}
+	}
+}
+
+// CaptureFault prints the error of the execution
+func (d *Debugger) CaptureFault(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	fmt.Fprintf(d.out, "fault at pc=%d op=%s: %v\n", pc, op.String(), err)
+	return nil
+}
+
+// CaptureEnd prints the result of the execution
+func (d *Debugger) CaptureEnd(output []byte, t time.Duration, err error) error {
+	if err != nil {
+		fmt.Fprintf(d.out, "end output=%x error=%v\n", output, err)
+	} else {
+		fmt.Fprintf(d.out, "end output=%x\n", output)
+	}
+	return nil
+}
+
+// CaptureEnter prints the internal call in the stepping mode
+func (d *Debugger) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, value *amount.Amount) error {
+	if d.stepping && !d.detached {
+		fmt.Fprintf(d.out, "enter %s %s input=%x\n", typ.String(), to.String(), input)
+	}
+	return nil
+}
+
+// CaptureExit prints the end of the internal call in the stepping mode
+func (d *Debugger) CaptureExit(output []byte, err error) error {
+	if d.stepping && !d.detached {
+		if err != nil {
+			fmt.Fprintf(d.out, "exit output=%x error=%v\n", output, err)
+		} else {
+			fmt.Fprintf(d.out, "exit output=%x\n", output)
+		}
+	}
+	return nil
+}
+
+func (d *Debugger) printWhere(pc uint64, op OpCode, contract *Contract, depth int) {
+	fmt.Fprintf(d.out, "[%d] %s pc=%d op=%s\n", depth, contract.Address().String(), pc, op.String())
+}
+
+// execute executes the command and returns true when the execution should be resumed
+func (d *Debugger) execute(args []string, env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "step", "s":
+		d.stepping = true
+		return true
+	case "continue", "c":
+		d.stepping = false
+		return true
+	case "quit", "q":
+		env.Cancel()
+		d.detached = true
+		return true
+	case "break", "b":
+		b, err := parseBreakpoint(args[1:])
+		if err != nil {
+			fmt.Fprintln(d.out, err)
+			return false
+		}
+		d.breakpoints = append(d.breakpoints, b)
+		fmt.Fprintf(d.out, "breakpoint %d: %s\n", len(d.breakpoints)-1, b.String())
+	case "breakpoints":
+		for i, b := range d.breakpoints {
+			fmt.Fprintf(d.out, "%d: %s\n", i, b.String())
+		}
+	case "delete":
+		if len(args) != 2 {
+			fmt.Fprintln(d.out, "usage: delete <n>")
+			return false
+		}
+		i, err := strconv.Atoi(args[1])
+		if err != nil || i < 0 || i >= len(d.breakpoints) {
+			fmt.Fprintln(d.out, "invalid breakpoint")
+			return false
+		}
+		d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+	case "where", "w":
+		d.printWhere(pc, op, contract, depth)
+	case "depth":
+		fmt.Fprintln(d.out, depth)
+	case "stack":
+		data := stack.Data()
+		for i := len(data) - 1; i >= 0; i-- {
+			fmt.Fprintf(d.out, "%04d  %s\n", len(data)-i-1, data[i].Hex())
+		}
+	case "memory", "mem":
+		fmt.Fprint(d.out, hex.Dump(memory.Data()))
+	case "storage":
+		if len(args) != 2 {
+			fmt.Fprintln(d.out, "usage: storage <slot>")
+			return false
+		}
+		slot, ok := new(big.Int).SetString(args[1], 0)
+		if !ok || slot.Sign() < 0 || slot.BitLen() > 256 {
+			fmt.Fprintln(d.out, "invalid slot")
+			return false
+		}
+		// the storage key is derived in the same way as SLOAD does
+		key := BytesToHash(new(Word).SetFromBig(slot).Bytes())
+		v := env.StateDB.GetState(contract.Address(), key)
+		fmt.Fprintf(d.out, "%x\n", v[:])
+	case "help", "h":
+		fmt.Fprint(d.out, debuggerHelp)
+	default:
+		fmt.Fprintf(d.out, "unknown command %s\n", args[0])
+	}
+	return false
+}
+
+const debuggerHelp = `step, s                  execute the next step
+continue, c              execute until the next breakpoint
+break, b <address> [pc]  pause at the pc of the contract or at any pc of it
+break, b op <opcode>     pause at the opcode
+breakpoints              list the breakpoints
+delete <n>               delete the breakpoint
+where, w                 print the current position
+depth                    print the call depth
+stack                    print the stack from the top
+memory, mem              print the memory
+storage <slot>           print the storage slot of the current contract
+quit, q                  abort the execution
+`
+
+func parseBreakpoint(args []string) (*breakpoint, error) {
+	if len(args) == 2 && args[0] == "op" {
+		op := StringToOp(strings.ToUpper(args[1]))
+		if op.String() != strings.ToUpper(args[1]) {
+			return nil, fmt.Errorf("invalid opcode %s", args[1])
+		}
+		return &breakpoint{op: op, isOp: true}, nil
+	}
+	if len(args) == 0 || len(args) > 2 {
+		return nil, fmt.Errorf("usage: break <address> [pc] | break op <opcode>")
+	}
+	addr, err := common.ParseAddress(args[0])
+	if err != nil {
+		return nil, err
+	}
+	b := &breakpoint{addr: addr}
+	if len(args) == 2 {
+		pc, err := strconv.ParseUint(args[1], 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		b.pc = pc
+		b.hasPC = true
+	}
+	return b, nil
+}
+
+// TerminalSession is a DebugSession which reads the commands from the reader line by line
+type TerminalSession struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+// NewTerminalSession returns a TerminalSession which prompts on the out
+func NewTerminalSession(in io.Reader, out io.Writer) *TerminalSession {
+	return &TerminalSession{
+		scanner: bufio.NewScanner(in),
+		out:     out,
+	}
+}
+
+// ReadCommand prompts and returns the next line
+func (s *TerminalSession) ReadCommand() (string, error) {
+	fmt.Fprint(s.out, "(debug) ")
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return s.scanner.Text(), nil
+}
+
+// Output returns the out
+func (s *TerminalSession) Output() io.Writer {
+	return s.out
+}
+
+// ScriptSession is a DebugSession which returns the commands of the script in order
+// It is used to drive the Debugger by the tests
+type ScriptSession struct {
+	commands []string
+	out      io.Writer
+}
+
+// NewScriptSession returns a ScriptSession which writes the output to the out
+func NewScriptSession(commands []string, out io.Writer) *ScriptSession {
+	return &ScriptSession{
+		commands: commands,
+		out:      out,
+	}
+}
+
+// ReadCommand returns the next command of the script
+func (s *ScriptSession) ReadCommand() (string, error) {
+	if len(s.commands) == 0 {
+		return "", io.EOF
+	}
+	cmd := s.commands[0]
+	s.commands = s.commands[1:]
+	return cmd, nil
+}
+
+// Output returns the out
+func (s *ScriptSession) Output() io.Writer {
+	return s.out
+}
@@ -0,0 +1,127 @@
+package vm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fletaio/core/amount"
+)
+
+// debug calls the address with the Debugger which is driven by the commands and returns the output of it
+func debug(t *testing.T, st *testState, to byte, commands ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	caller := testAddress(1)
+	st.CreateAccount(caller, "")
+	evm := newTestEVM(st, Config{Debug: true, Tracer: NewDebugger(NewScriptSession(commands, &out))})
+	_, err := evm.Call(AccountRef(caller), testAddress(to), nil, amount.NewCoinAmount(0, 0))
+	return out.String(), err
+}
+
+// debugState returns the state with the adder at the address 2 and its caller at the address 3
+func debugState(t *testing.T) *testState {
+	st := newTestState()
+	// PUSH1 at pc=0 and pc=2, ADD at pc=4 and STOP at pc=5
+	st.deploy(testAddress(2), mustAssemble(t, "PUSH1 1\nPUSH1 2\nADD\nSTOP"))
+	st.deploy(testAddress(3), mustAssemble(t, callSource(testAddress(2), 0)+"STOP"))
+	return st
+}
+
+// checkOutput checks that the output contains the lines in order
+func checkOutput(t *testing.T, out string, lines ...string) {
+	t.Helper()
+	rest := out
+	for _, l := range lines {
+		i := strings.Index(rest, l)
+		if i < 0 {
+			t.Fatalf("the output doesn't have %q in order:\n%s", l, out)
+		}
+		rest = rest[i+len(l):]
+	}
+}
+
+func TestDebuggerStep(t *testing.T) {
+	st := debugState(t)
+	adder := testAddress(2).String()
+	out, err := debug(t, st, 2, "step", "step", "stack", "continue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOutput(t, out,
+		"[1] "+adder+" pc=0 op=PUSH1\n",
+		"[1] "+adder+" pc=2 op=PUSH1\n",
+		"[1] "+adder+" pc=4 op=ADD\n",
+		"0000  0x2\n0001  0x1\n",
+		"end output=\n",
+	)
+	if strings.Contains(out, "pc=5") {
+		t.Fatalf("the debugger paused after the continue:\n%s", out)
+	}
+}
+
+func TestDebuggerBreakpoints(t *testing.T) {
+	st := debugState(t)
+	adder := testAddress(2).String()
+
+	out, err := debug(t, st, 3, "break op ADD", "continue", "where", "continue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOutput(t, out,
+		"breakpoint 0: op ADD\n",
+		"breakpoint op ADD\n",
+		"[2] "+adder+" pc=4 op=ADD\n",
+		"[2] "+adder+" pc=4 op=ADD\n",
+		"end output=\n",
+	)
+
+	out, err = debug(t, st, 3, "break "+adder+" 2", "breakpoints", "continue", "step", "continue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOutput(t, out,
+		"breakpoint 0: "+adder+" pc=2\n",
+		"0: "+adder+" pc=2\n",
+		"breakpoint "+adder+" pc=2\n",
+		"[2] "+adder+" pc=2 op=PUSH1\n",
+		"[2] "+adder+" pc=4 op=ADD\n",
+		"end output=\n",
+	)
+
+	// the deleted breakpoint doesn't pause the execution
+	out, err = debug(t, st, 3, "break op ADD", "delete 0", "continue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "op=ADD") {
+		t.Fatalf("the execution paused at the deleted breakpoint:\n%s", out)
+	}
+}
+
+func TestDebuggerQuit(t *testing.T) {
+	st := debugState(t)
+	st.deploy(testAddress(4), mustAssemble(t, `
+		loop:
+		JUMPDEST
+		JUMP loop
+	`))
+	out, err := debug(t, st, 4, "step", "quit")
+	if err != ErrExecutionAborted {
+		t.Fatalf("got error %v, want %v", err, ErrExecutionAborted)
+	}
+	checkOutput(t, out, "end output= error="+ErrExecutionAborted.Error()+"\n")
+}
+
+func TestDebuggerDetach(t *testing.T) {
+	st := debugState(t)
+	// the end of the script detaches the debugger and the execution runs to the end
+	out, err := debug(t, st, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOutput(t, out, "pc=0 op=PUSH1\n", "end output=\n")
+	if strings.Count(out, " op=") != 1 {
+		t.Fatalf("the detached debugger paused again:\n%s", out)
+	}
+}
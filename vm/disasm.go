@@ -0,0 +1,237 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Instruction is an instruction of the disassembled code
+type Instruction struct {
+	Offset    uint64
+	Op        OpCode
+	Data      []byte // push data
+	Truncated bool   // the push data is cut by the end of the code
+	JumpDest  bool   // a valid jump destination
+	Valid     bool   // the opcode is executable by the rules of the disassembly
+}
+
+// validOpcodes are the executable opcodes of the instruction sets by the fork
+// It is filled in init because the instruction sets refer to the Create which validates the code by the instructions
+var validOpcodes [LatestFork + 1][256]bool
+
+func init() {
+	for f := Frontier; f <= LatestFork; f++ {
+		rules := NewRules(f)
+		set := rules.instructionSet()
+		for i := range set {
+			validOpcodes[f][i] = set[i].valid
+		}
+	}
+}
+
+// validOpcodesOf returns the executable opcodes of the rules, nil means the rules of the LatestFork
+func validOpcodesOf(rules *Rules) *[256]bool {
+	if rules == nil {
+		return &validOpcodes[LatestFork]
+	}
+	return &validOpcodes[rules.Fork]
+}
+
+// String returns the instruction in the form of "OP 0xdata"
+func (in *Instruction) String() string {
+	var name string
+	if in.Valid {
+		name = in.Op.String()
+	} else {
+		name = fmt.Sprintf("INVALID(0x%02x)", byte(in.Op))
+	}
+	if in.Op.IsPush() {
+		name += fmt.Sprintf(" 0x%x", in.Data)
+		if in.Truncated {
+			name += " (truncated)"
+		}
+	}
+	return name
+}
+
+// Disassemble returns the instructions of the code by the instruction set of the rules (nil means the rules of the LatestFork)
+// The JUMPDESTs are marked by the same analysis that validates the jumps of the interpreter
+func Disassemble(code []byte, rules *Rules) []Instruction {
+	bitmap := codeBitmap(code)
+	valid := validOpcodesOf(rules)
+	list := []Instruction{}
+	for pc := uint64(0); pc < uint64(len(code)); {
+		op := OpCode(code[pc])
+		in := Instruction{
+			Offset:   pc,
+			Op:       op,
+			JumpDest: op == JUMPDEST && bitmap.codeSegment(pc),
+			Valid:    valid[op],
+		}
+		pc++
+		if op.IsPush() {
+			size := uint64(op - PUSH1 + 1)
+			end := pc + size
+			if end > uint64(len(code)) {
+				end = uint64(len(code))
+				in.Truncated = true
+			}
+			in.Data = code[pc:end]
+			pc = end
+		}
+		list = append(list, in)
+	}
+	return list
+}
+
+// Disassembly is the disassembled contract code
+// The creation code is separated into the constructor and the runtime code,
+// and the solc metadata trailer is separated from the runtime code
+// The offsets of the runtime code are relative to the start of it like its jump destinations
+type Disassembly struct {
+	Constructor     []Instruction // nil when the code is a runtime code
+	Runtime         []Instruction
+	RuntimeOffset   uint64 // offset of the runtime code in the creation code
+	Metadata        []byte // solc metadata trailer (CBOR) of the runtime code
+	MetadataOffset  uint64
+	ConstructorArgs []byte // data after the runtime code in the creation code
+}
+
+// DisassembleContract returns the disassembly of the creation code or the deployed code by the rules
+func DisassembleContract(code []byte, rules *Rules) *Disassembly {
+	d := &Disassembly{}
+	runtime := code
+	if offset, size, ok := findRuntimeCode(code); ok {
+		d.Constructor = Disassemble(code[:offset], rules)
+		runtime = code[offset : offset+size]
+		d.RuntimeOffset = offset
+		d.ConstructorArgs = code[offset+size:]
+	}
+	if n := metadataSize(runtime); n > 0 {
+		d.Metadata = runtime[len(runtime)-n:]
+		runtime = runtime[:len(runtime)-n]
+		d.MetadataOffset = uint64(len(runtime))
+	}
+	d.Runtime = Disassemble(runtime, rules)
+	return d
+}
+
+// WriteTo writes the listing of the disassembly
+func (d *Disassembly) WriteTo(w io.Writer) (int64, error) {
+	var buffer bytes.Buffer
+	if d.Constructor != nil {
+		buffer.WriteString("; constructor\n")
+		writeInstructions(&buffer, d.Constructor)
+		fmt.Fprintf(&buffer, "; runtime at 0x%06x\n", d.RuntimeOffset)
+	}
+	writeInstructions(&buffer, d.Runtime)
+	if len(d.Metadata) > 0 {
+		fmt.Fprintf(&buffer, "; metadata at 0x%06x\n.data 0x%x\n", d.MetadataOffset, d.Metadata)
+	}
+	if len(d.ConstructorArgs) > 0 {
+		fmt.Fprintf(&buffer, "; constructor arguments\n.data 0x%x\n", d.ConstructorArgs)
+	}
+	return buffer.WriteTo(w)
+}
+
+// String returns the listing of the disassembly
+func (d *Disassembly) String() string {
+	var buffer bytes.Buffer
+	d.WriteTo(&buffer)
+	return buffer.String()
+}
+
+func writeInstructions(w io.Writer, list []Instruction) {
+	for i := range list {
+		in := &list[i]
+		if in.JumpDest {
+			fmt.Fprintf(w, "%06x: %-40s ; jump destination\n", in.Offset, in.String())
+		} else {
+			fmt.Fprintf(w, "%06x: %s\n", in.Offset, in.String())
+		}
+	}
+}
+
+// metadataSize returns the size of the solc metadata trailer of the code or 0
+// The trailer is a CBOR map which is followed by the 2 bytes big endian length of it
+func metadataSize(code []byte) int {
+	if len(code) < 2 {
+		return 0
+	}
+	n := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	if n == 0 || n+2 > len(code) {
+		return 0
+	}
+	cbor := code[len(code)-2-n : len(code)-2]
+	// map with 1 to 5 pairs
+	if cbor[0] < 0xa1 || cbor[0] > 0xa5 {
+		return 0
+	}
+	for _, key := range [][]byte{
+		[]byte("\x65bzzr0"),
+		[]byte("\x65bzzr1"),
+		[]byte("\x64ipfs"),
+		[]byte("\x64solc"),
+	} {
+		if bytes.Contains(cbor, key) {
+			return n + 2
+		}
+	}
+	return 0
+}
+
+// findRuntimeCode finds the runtime code which is returned by the constructor of the creation code
+// It looks for the CODECOPY which copies the latter part of the code and is followed by RETURN
+// as the constructor of solc does (PUSH size DUP1 PUSH offset PUSH 0 CODECOPY PUSH 0 RETURN)
+func findRuntimeCode(code []byte) (uint64, uint64, bool) {
+	// the validity of the opcodes doesn't matter to find the runtime code
+	list := Disassemble(code, nil)
+	// values of the stack which are known by the preceding PUSH and DUP in the block
+	known := []*Word{}
+	pop := func() *Word {
+		if len(known) == 0 {
+			return nil
+		}
+		v := known[len(known)-1]
+		known = known[:len(known)-1]
+		return v
+	}
+	for i, in := range list {
+		switch {
+		case in.Op.IsPush():
+			known = append(known, new(Word).SetBytes(in.Data))
+		case in.Op >= DUP1 && in.Op <= DUP16:
+			n := int(in.Op-DUP1) + 1
+			if n <= len(known) {
+				known = append(known, known[len(known)-n])
+			} else {
+				known = append(known, nil)
+			}
+		case in.Op == CODECOPY:
+			pop()
+			offset, size := pop(), pop()
+			if offset == nil || size == nil || !offset.IsUint64() || !size.IsUint64() {
+				known = known[:0]
+				continue
+			}
+			o, s := offset.Uint64(), size.Uint64()
+			if o <= in.Offset || s == 0 || o+s > uint64(len(code)) || o+s < o {
+				known = known[:0]
+				continue
+			}
+			for _, next := range list[i+1:] {
+				if next.Op == RETURN {
+					return o, s, true
+				}
+				if next.Op == JUMP || next.Op == JUMPI || next.Op == JUMPDEST || next.Op == STOP {
+					break
+				}
+			}
+			known = known[:0]
+		default:
+			known = known[:0]
+		}
+	}
+	return 0, 0, false
+}
@@ -19,11 +19,32 @@ package vm
 import "errors"
 
 var (
-	ErrDepth                    = errors.New("max call depth exceeded")
-	ErrTraceLimitReached        = errors.New("the number of logs reached the specified limit")
-	ErrInsufficientBalance      = errors.New("insufficient balance for transfer")
-	ErrContractAddressCollision = errors.New("contract address collision")
-	ErrExistContract            = errors.New("exist contract")
-	ErrNotExistContract         = errors.New("not exist contract")
-	ErrInvalidContract          = errors.New("invalid contract")
+	ErrDepth                     = errors.New("max call depth exceeded")
+	ErrTraceLimitReached         = errors.New("the number of logs reached the specified limit")
+	ErrInsufficientBalance       = errors.New("insufficient balance for transfer")
+	ErrContractAddressCollision  = errors.New("contract address collision")
+	ErrExistContract             = errors.New("exist contract")
+	ErrNotExistContract          = errors.New("not exist contract")
+	ErrInvalidContract           = errors.New("invalid contract")
+	ErrNotSupported              = errors.New("not supported")
+	ErrSuicidedContract          = errors.New("suicided contract")
+	ErrExecutionAborted          = errors.New("execution aborted")
+	ErrMemoryLimitExceeded       = errors.New("memory limit exceeded")
+	ErrReturnDataLimitExceeded   = errors.New("return data limit exceeded")
+	ErrLogLimitExceeded          = errors.New("log limit exceeded")
+	ErrLogDataLimitExceeded      = errors.New("log data limit exceeded")
+	ErrStorageWriteLimitExceeded = errors.New("storage write limit exceeded")
+	ErrStepLimitExceeded         = errors.New("step limit exceeded")
+	ErrInitCodeSizeExceeded      = errors.New("max init code size exceeded")
+	ErrInvalidCodePrefix         = errors.New("invalid code: must not begin with 0xef")
+	ErrForbiddenOpcode           = errors.New("forbidden opcode")
+	ErrInvalidOpcode             = errors.New("invalid opcode")
+	ErrTruncatedPushData         = errors.New("truncated push data")
+	ErrUnknownFork               = errors.New("unknown fork")
+	ErrInvalidForkOrder          = errors.New("invalid fork order")
+	ErrInvalidCodePolicyOrder    = errors.New("invalid code policy order")
+	ErrReservedPrecompileAddress = errors.New("invalid custom precompile address")
+	ErrExistPrecompile           = errors.New("exist precompiled contract")
+	ErrPrecompileNotPayable      = errors.New("precompiled contract without the account is not payable")
+	ErrNotExistAccount           = errors.New("not exist account")
 )
@@ -37,17 +37,27 @@ type (
 	// GetHashFunc returns the nth block hash in the blockchain
 	// and is used by the BLOCKHASH EVM op code.
 	GetHashFunc func(uint64) hash.Hash256
+	// GetAddressByNameFunc returns the address of the account name
+	// and is used by the name resolver precompiled contract.
+	GetAddressByNameFunc func(string) (common.Address, error)
 )
 
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
-func run(evm *EVM, contract *Contract, input []byte) ([]byte, error) {
+// The returned data of both of them is limited by the MaxReturnDataSize.
+func run(evm *EVM, contract *Contract, input []byte) (ret []byte, err error) {
+	var p PrecompiledContract
 	if contract.CodeAddr != nil {
-		precompiles := PrecompiledContractsByzantium
-		if p := precompiles[*contract.CodeAddr]; p != nil {
-			return RunPrecompiledContract(p, input, contract)
-		}
+		p = evm.precompile(*contract.CodeAddr)
+	}
+	if p != nil {
+		ret, err = RunPrecompiledContract(evm, p, input, contract)
+	} else {
+		ret, err = evm.interpreter.Run(contract, input)
+	}
+	if max := evm.vmConfig.MaxReturnDataSize; max > 0 && uint64(len(ret)) > max {
+		return nil, ErrReturnDataLimitExceeded
 	}
-	return evm.interpreter.Run(contract, input)
+	return ret, err
 }
 
 // Context provides the EVM with auxiliary information. Once provided
@@ -60,6 +70,8 @@ type Context struct {
 	Transfer TransferFunc
 	// GetHash returns the hash corresponding to n
 	GetHash GetHashFunc
+	// GetAddressByName returns the address corresponding to the account name
+	GetAddressByName GetAddressByNameFunc
 
 	// Message information
 	Origin common.Address // Provides information for ORIGIN
@@ -91,33 +103,153 @@ type EVM struct {
 	// virtual machine configuration options used to initialise the
 	// evm.
 	vmConfig Config
+	// rules are the semantics of the fork of the execution
+	rules Rules
+	// precompiles are the precompiled contracts of the evm
+	precompiles *PrecompileSet
 	// global (to this context) ethereum virtual machine
 	// used throughout the execution of the tx.
 	interpreter *Interpreter
 	// abort is used to abort the EVM calling operations
 	// NOTE: must be set atomically
 	abort int32
+
+	// logCount, logDataSize, storageWrites and steps are the resource usages of
+	// the transaction which are limited by the vmConfig
+	logCount      int
+	logDataSize   uint64
+	storageWrites int
+	steps         uint64
+	// memorySize is the total memory size of the active call frames
+	memorySize uint64
+
+	// batch is true while the top level calls are traced as the children of a single frame
+	batch bool
 }
 
 // NewEVM returns a new EVM. The returned EVM is not thread safe and should
 // only ever be used *once*.
 func NewEVM(ctx Context, statedb StateDB, vmConfig Config) *EVM {
+	if vmConfig.Debug {
+		if t, is := vmConfig.Tracer.(StateDBTracer); is {
+			statedb = t.WrapStateDB(statedb)
+		}
+	}
 	evm := &EVM{
 		Context:  ctx,
 		StateDB:  statedb,
 		vmConfig: vmConfig,
 	}
+	if vmConfig.Rules != nil {
+		evm.rules = *vmConfig.Rules
+	} else {
+		evm.rules = NewRules(LatestFork)
+	}
+	if vmConfig.Precompiles != nil {
+		evm.precompiles = vmConfig.Precompiles.Clone()
+	} else {
+		evm.precompiles = NewPrecompileSet(evm.rules.Fork)
+	}
 
 	evm.interpreter = NewInterpreter(evm, vmConfig)
 	return evm
 }
 
+// precompile returns the precompiled contract of the address or nil
+func (evm *EVM) precompile(addr common.Address) PrecompiledContract {
+	return evm.precompiles.Get(addr)
+}
+
+// setState sets the storage value of the address within the storage write limit
+func (evm *EVM) setState(addr common.Address, key hash.Hash256, value hash.Hash256) error {
+	evm.storageWrites++
+	if evm.vmConfig.MaxStorageWrites > 0 && evm.storageWrites > evm.vmConfig.MaxStorageWrites {
+		return ErrStorageWriteLimitExceeded
+	}
+	evm.StateDB.SetState(addr, key, value)
+	return nil
+}
+
+// addLog adds the log of the address within the log limits
+func (evm *EVM) addLog(addr common.Address, topics []hash.Hash256, data []byte) error {
+	evm.logCount++
+	if evm.vmConfig.MaxLogCount > 0 && evm.logCount > evm.vmConfig.MaxLogCount {
+		return ErrLogLimitExceeded
+	}
+	evm.logDataSize += uint64(len(data))
+	if evm.vmConfig.MaxLogDataSize > 0 && evm.logDataSize > evm.vmConfig.MaxLogDataSize {
+		return ErrLogDataLimitExceeded
+	}
+	evm.StateDB.AddLog(&Log{
+		Address: addr,
+		Topics:  topics,
+		Data:    data,
+		// This is a non-consensus field, but assigned here because
+		// core/state doesn't know the current block number.
+		BlockNumber: evm.BlockNumber.Uint64(),
+	})
+	return nil
+}
+
+// Rules returns the rules of the fork of the execution
+func (evm *EVM) Rules() Rules {
+	return evm.rules
+}
+
+// codePolicy returns the code policy of the config or the code policy of the rules
+func (evm *EVM) codePolicy() *CodePolicy {
+	if evm.vmConfig.CodePolicy != nil {
+		return evm.vmConfig.CodePolicy
+	}
+	return evm.rules.CodePolicy
+}
+
 // Cancel cancels any running EVM operation. This may be called concurrently and
 // it's safe to be called multiple times.
 func (evm *EVM) Cancel() {
 	atomic.StoreInt32(&evm.abort, 1)
 }
 
+// Cancelled returns true if Cancel has been called
+func (evm *EVM) Cancelled() bool {
+	return atomic.LoadInt32(&evm.abort) == 1
+}
+
+// captureEnter notifies the tracer of the start of the call frame
+// The top level frame is notified by CaptureStart and the others by CaptureEnter
+func (evm *EVM) captureEnter(typ OpCode, from common.Address, to common.Address, input []byte, value *amount.Amount) {
+	if evm.depth == 0 && !evm.batch {
+		evm.vmConfig.Tracer.CaptureStart(from, to, typ == CREATE, input, value)
+	} else {
+		evm.vmConfig.Tracer.CaptureEnter(typ, from, to, input, value)
+	}
+}
+
+// captureExit notifies the tracer of the end of the call frame
+func (evm *EVM) captureExit(start time.Time, ret []byte, err error) {
+	if evm.depth == 0 && !evm.batch {
+		evm.vmConfig.Tracer.CaptureEnd(ret, time.Since(start), err)
+	} else {
+		evm.vmConfig.Tracer.CaptureExit(ret, err)
+	}
+}
+
+// Batch executes the fn as a single top level frame from the address
+// The top level calls of the fn are notified to the tracer by CaptureEnter as the children of the frame,
+// so the tracer gets one root frame for the calls of a transaction
+func (evm *EVM) Batch(from common.Address, fn func() error) (err error) {
+	if !evm.vmConfig.Debug || evm.batch {
+		return fn()
+	}
+	evm.vmConfig.Tracer.CaptureStart(from, from, false, nil, nil)
+	evm.batch = true
+	defer func(start time.Time) {
+		evm.batch = false
+		evm.vmConfig.Tracer.CaptureEnd(nil, time.Since(start), err)
+	}(time.Now())
+	return fn()
+}
+
 // Call executes the contract associated with the addr with the given input as
 // parameters. It also handles any necessary value transfer required and takes
 // the necessary steps to create accounts and reverses the state in case of an
@@ -126,6 +258,10 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, valu
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		return nil, nil
 	}
+	if evm.vmConfig.Debug {
+		evm.captureEnter(CALL, caller.Address(), addr, input, value)
+		defer func(start time.Time) { evm.captureExit(start, ret, err) }(time.Now())
+	}
 
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(CallCreateDepth) {
@@ -136,17 +272,30 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, valu
 		return nil, ErrInsufficientBalance
 	}
 
-	var (
-		to       = AccountRef(addr)
-		snapshot = evm.StateDB.Snapshot()
-	)
-	defer evm.StateDB.RevertToSnapshot(snapshot)
-	if !evm.StateDB.Exist(addr) {
+	to := AccountRef(addr)
+	isPrecompile := evm.precompile(addr) != nil
+	if !isPrecompile && !evm.StateDB.Exist(addr) {
 		return nil, ErrNotExistContract
 	}
+	// the value cannot be added to the balance of the precompiled contract which doesn't have the account
+	if isPrecompile && !value.IsZero() && !evm.StateDB.Exist(addr) {
+		return nil, ErrPrecompileNotPayable
+	}
+	if evm.StateDB.HasSuicided(addr) {
+		return nil, ErrSuicidedContract
+	}
+	snapshot := evm.StateDB.Snapshot()
+	defer evm.StateDB.RevertToSnapshot(snapshot)
 	evm.Transfer(evm.StateDB, caller.Address(), to.Address(), value)
+	// the rent is paid after the value is credited, so the value of the call can revive the dormant contract
+	// and the rent of the failed call is reverted with the value, the ChargeRent of the node charges it at the epoch boundary
+	if !isPrecompile {
+		if err := evm.StateDB.PayRent(addr); err != nil {
+			return nil, err
+		}
+	}
 	code := evm.StateDB.GetCode(addr)
-	if len(code) == 0 {
+	if !isPrecompile && len(code) == 0 {
 		return nil, ErrInvalidContract
 	}
 
@@ -155,16 +304,6 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, valu
 	contract := NewContract(caller, to, value)
 	contract.SetCallCode(&addr, evm.StateDB.GetCodeHash(addr), code)
 
-	start := time.Now()
-
-	// Capture the tracer start/end events in debug mode
-	if evm.vmConfig.Debug && evm.depth == 0 {
-		evm.vmConfig.Tracer.CaptureStart(caller.Address(), addr, false, input, value)
-
-		defer func() { // Lazy evaluation of the parameters
-			evm.vmConfig.Tracer.CaptureEnd(ret, time.Since(start), err)
-		}()
-	}
 	ret, err = run(evm, contract, input)
 
 	// When an error was returned by the EVM or when setting the creation code
@@ -187,6 +326,10 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		return nil, nil
 	}
+	if evm.vmConfig.Debug {
+		evm.captureEnter(CALLCODE, caller.Address(), addr, input, value)
+		defer func(start time.Time) { evm.captureExit(start, ret, err) }(time.Now())
+	}
 
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(CallCreateDepth) {
@@ -197,6 +340,10 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 		return nil, ErrInsufficientBalance
 	}
 
+	// the code of the addr is executed in the context of the caller, so the caller pays the rent of its storage
+	if err := evm.StateDB.PayRent(caller.Address()); err != nil {
+		return nil, err
+	}
 	var (
 		snapshot = evm.StateDB.Snapshot()
 		to       = AccountRef(caller.Address())
@@ -224,11 +371,19 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		return nil, nil
 	}
+	if evm.vmConfig.Debug {
+		evm.captureEnter(DELEGATECALL, caller.Address(), addr, input, nil)
+		defer func(start time.Time) { evm.captureExit(start, ret, err) }(time.Now())
+	}
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(CallCreateDepth) {
 		return nil, ErrDepth
 	}
 
+	// the code of the addr is executed in the context of the caller, so the caller pays the rent of its storage
+	if err := evm.StateDB.PayRent(caller.Address()); err != nil {
+		return nil, err
+	}
 	var (
 		snapshot = evm.StateDB.Snapshot()
 		to       = AccountRef(caller.Address())
@@ -254,6 +409,10 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	if evm.vmConfig.NoRecursion && evm.depth > 0 {
 		return nil, nil
 	}
+	if evm.vmConfig.Debug {
+		evm.captureEnter(STATICCALL, caller.Address(), addr, input, nil)
+		defer func(start time.Time) { evm.captureExit(start, ret, err) }(time.Now())
+	}
 	// Fail if we're trying to execute above the call depth limit
 	if evm.depth > int(CallCreateDepth) {
 		return nil, ErrDepth
@@ -266,6 +425,11 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 		defer func() { evm.interpreter.readOnly = false }()
 	}
 
+	if evm.precompile(addr) == nil {
+		if err := evm.StateDB.PayRent(addr); err != nil {
+			return nil, err
+		}
+	}
 	var (
 		to       = AccountRef(addr)
 		snapshot = evm.StateDB.Snapshot()
@@ -289,6 +453,10 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 
 // Create creates a new contract using code as deployment code.
 func (evm *EVM) Create(caller ContractRef, contractAddr common.Address, contractName string, code []byte, value *amount.Amount) (ret []byte, err error) {
+	if evm.vmConfig.Debug {
+		evm.captureEnter(CREATE, caller.Address(), contractAddr, code, value)
+		defer func(start time.Time) { evm.captureExit(start, ret, err) }(time.Now())
+	}
 
 	// Depth check execution. Fail if we're trying to execute above the
 	// limit.
@@ -298,6 +466,11 @@ func (evm *EVM) Create(caller ContractRef, contractAddr common.Address, contract
 	if !evm.CanTransfer(evm.StateDB, caller.Address(), value) {
 		return nil, ErrInsufficientBalance
 	}
+	if policy := evm.codePolicy(); policy != nil {
+		if err := policy.ValidateInitCode(code, &evm.rules); err != nil {
+			return nil, err
+		}
+	}
 	if evm.StateDB.Exist(contractAddr) {
 		return nil, ErrExistContract
 	}
@@ -325,30 +498,26 @@ func (evm *EVM) Create(caller ContractRef, contractAddr common.Address, contract
 		return nil, nil
 	}
 
-	if evm.vmConfig.Debug && evm.depth == 0 {
-		evm.vmConfig.Tracer.CaptureStart(caller.Address(), contractAddr, true, code, value)
-	}
-	start := time.Now()
-
 	ret, err = run(evm, contract, nil)
 
 	// check whether the max code size has been exceeded
-	maxCodeSizeExceeded := len(ret) > MaxCodeSize
+	maxCodeSizeExceeded := evm.rules.MaxCodeSize > 0 && len(ret) > evm.rules.MaxCodeSize
+	// Assign err if contract code size exceeds the max while the err is still empty.
+	if maxCodeSizeExceeded && err == nil {
+		err = errMaxCodeSizeExceeded
+	}
+	// validate the runtime code before it is stored
+	if policy := evm.codePolicy(); policy != nil && err == nil {
+		err = policy.ValidateRuntimeCode(ret, &evm.rules)
+	}
 	// if the contract creation ran successfully and no errors were returned
-	if err == nil && !maxCodeSizeExceeded {
+	if err == nil {
 		evm.StateDB.SetCode(contractAddr, ret)
 	}
 
 	// When an error was returned by the EVM or when setting the creation code
 	// above we revert to the snapshot and consume any remaining. Additionally
 	// when we're in homestead this also counts for code storage errors.
-	// Assign err if contract code size exceeds the max while the err is still empty.
-	if maxCodeSizeExceeded && err == nil {
-		err = errMaxCodeSizeExceeded
-	}
-	if evm.vmConfig.Debug && evm.depth == 0 {
-		evm.vmConfig.Tracer.CaptureEnd(ret, time.Since(start), err)
-	}
 	if err == nil {
 		evm.StateDB.CommitSnapshot(snapshot)
 	}
@@ -19,18 +19,14 @@ package vm
 import (
 	"errors"
 	"fmt"
-	"math/big"
 	"strings"
 
 	ecrypto "github.com/fletaio/common/crypto"
 	"github.com/fletaio/common/hash"
 	"github.com/fletaio/core/amount"
-	"github.com/fletaio/solidity/vm/math"
 )
 
 var (
-	bigZero                  = new(big.Int)
-	tt255                    = math.BigPow(2, 255)
 	errWriteProtection       = errors.New("evm: write protection")
 	errReturnDataOutOfBounds = errors.New("evm: return data out of bounds")
 	errExecutionReverted     = errors.New("evm: execution reverted")
@@ -39,274 +35,157 @@ var (
 
 func opAdd(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-	math.U256(y.Add(x, y))
-
-	evm.interpreter.intPool.put(x)
+	y.Add(&x, y)
 	return nil, nil
 }
 
 func opSub(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-	math.U256(y.Sub(x, y))
-
-	evm.interpreter.intPool.put(x)
+	y.Sub(&x, y)
 	return nil, nil
 }
 
 func opMul(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	stack.push(math.U256(x.Mul(x, y)))
-
-	evm.interpreter.intPool.put(y)
-
+	x, y := stack.pop(), stack.peek()
+	y.Mul(&x, y)
 	return nil, nil
 }
 
 func opDiv(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-	if y.Sign() != 0 {
-		math.U256(y.Div(x, y))
-	} else {
-		y.SetUint64(0)
-	}
-	evm.interpreter.intPool.put(x)
+	y.Div(&x, y)
 	return nil, nil
 }
 
 func opSdiv(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := math.S256(stack.pop()), math.S256(stack.pop())
-	res := evm.interpreter.intPool.getZero()
-
-	if y.Sign() == 0 || x.Sign() == 0 {
-		stack.push(res)
-	} else {
-		if x.Sign() != y.Sign() {
-			res.Div(x.Abs(x), y.Abs(y))
-			res.Neg(res)
-		} else {
-			res.Div(x.Abs(x), y.Abs(y))
-		}
-		stack.push(math.U256(res))
-	}
-	evm.interpreter.intPool.put(x, y)
+	x, y := stack.pop(), stack.peek()
+	y.SDiv(&x, y)
 	return nil, nil
 }
 
 func opMod(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	if y.Sign() == 0 {
-		stack.push(x.SetUint64(0))
-	} else {
-		stack.push(math.U256(x.Mod(x, y)))
-	}
-	evm.interpreter.intPool.put(y)
+	x, y := stack.pop(), stack.peek()
+	y.Mod(&x, y)
 	return nil, nil
 }
 
 func opSmod(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := math.S256(stack.pop()), math.S256(stack.pop())
-	res := evm.interpreter.intPool.getZero()
-
-	if y.Sign() == 0 {
-		stack.push(res)
-	} else {
-		if x.Sign() < 0 {
-			res.Mod(x.Abs(x), y.Abs(y))
-			res.Neg(res)
-		} else {
-			res.Mod(x.Abs(x), y.Abs(y))
-		}
-		stack.push(math.U256(res))
-	}
-	evm.interpreter.intPool.put(x, y)
+	x, y := stack.pop(), stack.peek()
+	y.SMod(&x, y)
 	return nil, nil
 }
 
 func opExp(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	base, exponent := stack.pop(), stack.pop()
-	stack.push(math.Exp(base, exponent))
-
-	evm.interpreter.intPool.put(base, exponent)
-
+	base, exponent := stack.pop(), stack.peek()
+	exponent.Exp(&base, exponent)
 	return nil, nil
 }
 
 func opSignExtend(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	back := stack.pop()
-	if back.Cmp(big.NewInt(31)) < 0 {
-		bit := uint(back.Uint64()*8 + 7)
-		num := stack.pop()
-		mask := back.Lsh(Big1, bit)
-		mask.Sub(mask, Big1)
-		if num.Bit(int(bit)) > 0 {
-			num.Or(num, mask.Not(mask))
-		} else {
-			num.And(num, mask)
-		}
-
-		stack.push(math.U256(num))
-	}
-
-	evm.interpreter.intPool.put(back)
+	back, num := stack.pop(), stack.peek()
+	num.SignExtend(&back, num)
 	return nil, nil
 }
 
 func opNot(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x := stack.peek()
-	math.U256(x.Not(x))
+	x.Not(x)
 	return nil, nil
 }
 
 func opLt(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-	if x.Cmp(y) < 0 {
+	if x.Lt(y) {
 		y.SetUint64(1)
 	} else {
-		y.SetUint64(0)
+		y.Clear()
 	}
-	evm.interpreter.intPool.put(x)
 	return nil, nil
 }
 
 func opGt(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-	if x.Cmp(y) > 0 {
+	if x.Gt(y) {
 		y.SetUint64(1)
 	} else {
-		y.SetUint64(0)
+		y.Clear()
 	}
-	evm.interpreter.intPool.put(x)
 	return nil, nil
 }
 
 func opSlt(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-
-	xSign := x.Cmp(tt255)
-	ySign := y.Cmp(tt255)
-
-	switch {
-	case xSign >= 0 && ySign < 0:
+	if x.Slt(y) {
 		y.SetUint64(1)
-
-	case xSign < 0 && ySign >= 0:
-		y.SetUint64(0)
-
-	default:
-		if x.Cmp(y) < 0 {
-			y.SetUint64(1)
-		} else {
-			y.SetUint64(0)
-		}
+	} else {
+		y.Clear()
 	}
-	evm.interpreter.intPool.put(x)
 	return nil, nil
 }
 
 func opSgt(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-
-	xSign := x.Cmp(tt255)
-	ySign := y.Cmp(tt255)
-
-	switch {
-	case xSign >= 0 && ySign < 0:
-		y.SetUint64(0)
-
-	case xSign < 0 && ySign >= 0:
+	if x.Sgt(y) {
 		y.SetUint64(1)
-
-	default:
-		if x.Cmp(y) > 0 {
-			y.SetUint64(1)
-		} else {
-			y.SetUint64(0)
-		}
+	} else {
+		y.Clear()
 	}
-	evm.interpreter.intPool.put(x)
 	return nil, nil
 }
 
 func opEq(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-	if x.Cmp(y) == 0 {
+	if x.Eq(y) {
 		y.SetUint64(1)
 	} else {
-		y.SetUint64(0)
+		y.Clear()
 	}
-	evm.interpreter.intPool.put(x)
 	return nil, nil
 }
 
 func opIszero(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x := stack.peek()
-	if x.Sign() > 0 {
-		x.SetUint64(0)
-	} else {
+	if x.IsZero() {
 		x.SetUint64(1)
+	} else {
+		x.Clear()
 	}
 	return nil, nil
 }
 
 func opAnd(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y := stack.pop(), stack.pop()
-	stack.push(x.And(x, y))
-
-	evm.interpreter.intPool.put(y)
+	x, y := stack.pop(), stack.peek()
+	y.And(&x, y)
 	return nil, nil
 }
 
 func opOr(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-	y.Or(x, y)
-
-	evm.interpreter.intPool.put(x)
+	y.Or(&x, y)
 	return nil, nil
 }
 
 func opXor(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	x, y := stack.pop(), stack.peek()
-	y.Xor(x, y)
-
-	evm.interpreter.intPool.put(x)
+	y.Xor(&x, y)
 	return nil, nil
 }
 
 func opByte(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	th, val := stack.pop(), stack.peek()
-	if th.Cmp(Big32) < 0 {
-		b := math.Byte(val, 32, int(th.Int64()))
-		val.SetUint64(uint64(b))
-	} else {
-		val.SetUint64(0)
-	}
-	evm.interpreter.intPool.put(th)
+	val.SetUint64(uint64(val.Byte(&th)))
 	return nil, nil
 }
 
 func opAddmod(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y, z := stack.pop(), stack.pop(), stack.pop()
-	if z.Cmp(bigZero) > 0 {
-		x.Add(x, y)
-		x.Mod(x, z)
-		stack.push(math.U256(x))
-	} else {
-		stack.push(x.SetUint64(0))
-	}
-	evm.interpreter.intPool.put(y, z)
+	x, y, z := stack.pop(), stack.pop(), stack.peek()
+	z.AddMod(&x, &y, z)
 	return nil, nil
 }
 
 func opMulmod(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	x, y, z := stack.pop(), stack.pop(), stack.pop()
-	if z.Cmp(bigZero) > 0 {
-		x.Mul(x, y)
-		x.Mod(x, z)
-		stack.push(math.U256(x))
-	} else {
-		stack.push(x.SetUint64(0))
-	}
-	evm.interpreter.intPool.put(y, z)
+	x, y, z := stack.pop(), stack.pop(), stack.peek()
+	z.MulMod(&x, &y, z)
 	return nil, nil
 }
 
@@ -315,16 +194,12 @@ func opMulmod(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *S
 // and pushes on the stack arg2 shifted to the left by arg1 number of bits.
 func opSHL(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	// Note, second operand is left in the stack; accumulate result into it, and no need to push it afterwards
-	shift, value := math.U256(stack.pop()), math.U256(stack.peek())
-	defer evm.interpreter.intPool.put(shift) // First operand back into the pool
-
-	if shift.Cmp(Big256) >= 0 {
-		value.SetUint64(0)
+	shift, value := stack.pop(), stack.peek()
+	if !shift.IsUint64() || shift.Uint64() >= 256 {
+		value.Clear()
 		return nil, nil
 	}
-	n := uint(shift.Uint64())
-	math.U256(value.Lsh(value, n))
-
+	value.Lsh(value, uint(shift.Uint64()))
 	return nil, nil
 }
 
@@ -333,16 +208,12 @@ func opSHL(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stac
 // and pushes on the stack arg2 shifted to the right by arg1 number of bits with zero fill.
 func opSHR(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	// Note, second operand is left in the stack; accumulate result into it, and no need to push it afterwards
-	shift, value := math.U256(stack.pop()), math.U256(stack.peek())
-	defer evm.interpreter.intPool.put(shift) // First operand back into the pool
-
-	if shift.Cmp(Big256) >= 0 {
-		value.SetUint64(0)
+	shift, value := stack.pop(), stack.peek()
+	if !shift.IsUint64() || shift.Uint64() >= 256 {
+		value.Clear()
 		return nil, nil
 	}
-	n := uint(shift.Uint64())
-	math.U256(value.Rsh(value, n))
-
+	value.Rsh(value, uint(shift.Uint64()))
 	return nil, nil
 }
 
@@ -350,70 +221,56 @@ func opSHR(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stac
 // The SAR instruction (arithmetic shift right) pops 2 values from the stack, first arg1 and then arg2,
 // and pushes on the stack arg2 shifted to the right by arg1 number of bits with sign extension.
 func opSAR(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	// Note, S256 returns (potentially) a new bigint, so we're popping, not peeking this one
-	shift, value := math.U256(stack.pop()), math.S256(stack.pop())
-	defer evm.interpreter.intPool.put(shift) // First operand back into the pool
-
-	if shift.Cmp(Big256) >= 0 {
-		if value.Sign() > 0 {
-			value.SetUint64(0)
-		} else {
-			value.SetInt64(-1)
-		}
-		stack.push(math.U256(value))
-		return nil, nil
+	shift, value := stack.pop(), stack.peek()
+	n := uint(256)
+	if shift.IsUint64() && shift.Uint64() < 256 {
+		n = uint(shift.Uint64())
 	}
-	n := uint(shift.Uint64())
-	value.Rsh(value, n)
-	stack.push(math.U256(value))
-
+	value.SRsh(value, n)
 	return nil, nil
 }
 
 func opSha3(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	offset, size := stack.pop(), stack.pop()
+	offset, size := stack.pop(), stack.peek()
 	data := memory.Get(offset.Int64(), size.Int64())
-	hash := ecrypto.Keccak256(data)
-
-	stack.push(evm.interpreter.intPool.get().SetBytes(hash))
-
-	evm.interpreter.intPool.put(offset, size)
+	size.SetBytes(ecrypto.Keccak256(data))
 	return nil, nil
 }
 
 func opAddress(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(AddressToBig(contract.Address()))
+	stack.push(AddressToWord(contract.Address()))
 	return nil, nil
 }
 
 func opBalance(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	slot := stack.peek()
-	slot.Set(evm.StateDB.GetBalance(BytesToAddress(slot.Bytes())).Int)
+	slot.SetFromBig(evm.StateDB.GetBalance(WordToAddress(slot)).Int)
 	return nil, nil
 }
 
 func opOrigin(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(AddressToBig(evm.Origin))
+	stack.push(AddressToWord(evm.Origin))
 	return nil, nil
 }
 
 func opCaller(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(AddressToBig(contract.Caller()))
+	stack.push(AddressToWord(contract.Caller()))
 	return nil, nil
 }
 
 func opCallValue(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(evm.interpreter.intPool.get().Set(contract.value.Int))
+	stack.push(new(Word).SetFromBig(contract.value.Int))
 	return nil, nil
 }
 
 func opCallDataLoad(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(evm.interpreter.intPool.get().SetBytes(getDataBig(contract.Input, stack.pop(), Big32)))
+	x := stack.peek()
+	x.SetBytes(getDataWord(contract.Input, x, new(Word).SetUint64(32)))
 	return nil, nil
 }
 
 func opCallDataSize(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(evm.interpreter.intPool.get().SetInt64(int64(len(contract.Input))))
+	stack.push(new(Word).SetUint64(uint64(len(contract.Input))))
 	return nil, nil
 }
 
@@ -423,14 +280,12 @@ func opCallDataCopy(pc *uint64, evm *EVM, contract *Contract, memory *Memory, st
 		dataOffset = stack.pop()
 		length     = stack.pop()
 	)
-	memory.Set(memOffset.Uint64(), length.Uint64(), getDataBig(contract.Input, dataOffset, length))
-
-	evm.interpreter.intPool.put(memOffset, dataOffset, length)
+	memory.Set(memOffset.Uint64(), length.Uint64(), getDataWord(contract.Input, &dataOffset, &length))
 	return nil, nil
 }
 
 func opReturnDataSize(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(evm.interpreter.intPool.get().SetUint64(uint64(len(evm.interpreter.returnData))))
+	stack.push(new(Word).SetUint64(uint64(len(evm.interpreter.returnData))))
 	return nil, nil
 }
 
@@ -439,12 +294,9 @@ func opReturnDataCopy(pc *uint64, evm *EVM, contract *Contract, memory *Memory,
 		memOffset  = stack.pop()
 		dataOffset = stack.pop()
 		length     = stack.pop()
-
-		end = evm.interpreter.intPool.get().Add(dataOffset, length)
 	)
-	defer evm.interpreter.intPool.put(memOffset, dataOffset, length, end)
-
-	if end.BitLen() > 64 || uint64(len(evm.interpreter.returnData)) < end.Uint64() {
+	end, overflow := new(Word).AddOverflow(&dataOffset, &length)
+	if overflow || !end.IsUint64() || uint64(len(evm.interpreter.returnData)) < end.Uint64() {
 		return nil, errReturnDataOutOfBounds
 	}
 	memory.Set(memOffset.Uint64(), length.Uint64(), evm.interpreter.returnData[dataOffset.Uint64():end.Uint64()])
@@ -454,15 +306,13 @@ func opReturnDataCopy(pc *uint64, evm *EVM, contract *Contract, memory *Memory,
 
 func opExtCodeSize(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	slot := stack.peek()
-	slot.SetUint64(uint64(evm.StateDB.GetCodeSize(BytesToAddress(slot.Bytes()))))
+	slot.SetUint64(uint64(evm.StateDB.GetCodeSize(WordToAddress(slot))))
 
 	return nil, nil
 }
 
 func opCodeSize(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	l := evm.interpreter.intPool.get().SetInt64(int64(len(contract.Code)))
-	stack.push(l)
-
+	stack.push(new(Word).SetUint64(uint64(len(contract.Code))))
 	return nil, nil
 }
 
@@ -472,87 +322,76 @@ func opCodeCopy(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack
 		codeOffset = stack.pop()
 		length     = stack.pop()
 	)
-	codeCopy := getDataBig(contract.Code, codeOffset, length)
+	codeCopy := getDataWord(contract.Code, &codeOffset, &length)
 	memory.Set(memOffset.Uint64(), length.Uint64(), codeCopy)
-
-	evm.interpreter.intPool.put(memOffset, codeOffset, length)
 	return nil, nil
 }
 
 func opExtCodeCopy(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	var (
-		addr       = BytesToAddress(stack.pop().Bytes())
+		a          = stack.pop()
+		addr       = WordToAddress(&a)
 		memOffset  = stack.pop()
 		codeOffset = stack.pop()
 		length     = stack.pop()
 	)
-	codeCopy := getDataBig(evm.StateDB.GetCode(addr), codeOffset, length)
+	codeCopy := getDataWord(evm.StateDB.GetCode(addr), &codeOffset, &length)
 	memory.Set(memOffset.Uint64(), length.Uint64(), codeCopy)
-
-	evm.interpreter.intPool.put(memOffset, codeOffset, length)
 	return nil, nil
 }
 
 func opBlockhash(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	num := stack.pop()
-
-	n := evm.interpreter.intPool.get().Sub(evm.BlockNumber, Big257)
-	if num.Cmp(n) > 0 && num.Cmp(evm.BlockNumber) < 0 {
-		stack.push(HashToBig(evm.GetHash(num.Uint64())))
+	num := stack.peek()
+	bn := evm.BlockNumber.Uint64()
+	if num.IsUint64() && num.Uint64() < bn && (bn < 257 || num.Uint64() > bn-257) {
+		num.Set(HashToWord(evm.GetHash(num.Uint64())))
 	} else {
-		stack.push(evm.interpreter.intPool.getZero())
+		num.Clear()
 	}
-	evm.interpreter.intPool.put(num, n)
 	return nil, nil
 }
 
 func opCoinbase(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(AddressToBig(evm.Coinbase))
+	stack.push(AddressToWord(evm.Coinbase))
 	return nil, nil
 }
 
 func opTimestamp(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(math.U256(evm.interpreter.intPool.get().Set(evm.Time)))
+	stack.push(new(Word).SetFromBig(evm.Time))
 	return nil, nil
 }
 
 func opNumber(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(math.U256(evm.interpreter.intPool.get().Set(evm.BlockNumber)))
+	stack.push(new(Word).SetFromBig(evm.BlockNumber))
 	return nil, nil
 }
 
 func opDifficulty(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(math.U256(evm.interpreter.intPool.get().Set(evm.Difficulty)))
+	stack.push(new(Word).SetFromBig(evm.Difficulty))
 	return nil, nil
 }
 
 func opPop(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	evm.interpreter.intPool.put(stack.pop())
+	stack.pop()
 	return nil, nil
 }
 
 func opMload(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	offset := stack.pop()
-	val := evm.interpreter.intPool.get().SetBytes(memory.Get(offset.Int64(), 32))
-	stack.push(val)
-
-	evm.interpreter.intPool.put(offset)
+	v := stack.peek()
+	v.SetBytes(memory.Get(v.Int64(), 32))
 	return nil, nil
 }
 
 func opMstore(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	// pop value of the stack
 	mStart, val := stack.pop(), stack.pop()
-	memory.Set32(mStart.Uint64(), val)
-
-	evm.interpreter.intPool.put(mStart, val)
+	memory.Set32(mStart.Uint64(), &val)
 	return nil, nil
 }
 
 func opMstore8(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	off, val := stack.pop().Int64(), stack.pop().Int64()
-	memory.store[off] = byte(val & 0xff)
-
+	off, val := stack.pop(), stack.pop()
+	memory.store[off.Int64()] = byte(val.Uint64() & 0xff)
 	return nil, nil
 }
 
@@ -564,39 +403,31 @@ func opSload(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *St
 }
 
 func opSstore(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	loc := BytesToHash(stack.pop().Bytes())
-	val := stack.pop()
-	evm.StateDB.SetState(contract.Address(), loc, BytesToHash(val.Bytes()))
-
-	evm.interpreter.intPool.put(val)
-	return nil, nil
+	loc, val := stack.pop(), stack.pop()
+	return nil, evm.setState(contract.Address(), BytesToHash(loc.Bytes()), BytesToHash(val.Bytes()))
 }
 
 func opJump(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	pos := stack.pop()
-	if !contract.jumpdests.has(contract.CodeHash, contract.Code, pos) {
+	if !contract.jumpdests.has(contract.CodeHash, contract.Code, &pos) {
 		nop := contract.GetOp(pos.Uint64())
-		return nil, fmt.Errorf("invalid jump destination (%v) %v", nop, pos)
+		return nil, fmt.Errorf("invalid jump destination (%v) %v", nop, &pos)
 	}
 	*pc = pos.Uint64()
-
-	evm.interpreter.intPool.put(pos)
 	return nil, nil
 }
 
 func opJumpi(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	pos, cond := stack.pop(), stack.pop()
-	if cond.Sign() != 0 {
-		if !contract.jumpdests.has(contract.CodeHash, contract.Code, pos) {
+	if !cond.IsZero() {
+		if !contract.jumpdests.has(contract.CodeHash, contract.Code, &pos) {
 			nop := contract.GetOp(pos.Uint64())
-			return nil, fmt.Errorf("invalid jump destination (%v) %v", nop, pos)
+			return nil, fmt.Errorf("invalid jump destination (%v) %v", nop, &pos)
 		}
 		*pc = pos.Uint64()
 	} else {
 		*pc++
 	}
-
-	evm.interpreter.intPool.put(pos, cond)
 	return nil, nil
 }
 
@@ -605,12 +436,12 @@ func opJumpdest(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack
 }
 
 func opPc(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(evm.interpreter.intPool.get().SetUint64(*pc))
+	stack.push(new(Word).SetUint64(*pc))
 	return nil, nil
 }
 
 func opMsize(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(evm.interpreter.intPool.get().SetInt64(int64(memory.Len())))
+	stack.push(new(Word).SetUint64(uint64(memory.Len())))
 	return nil, nil
 }
 
@@ -629,11 +460,10 @@ func opCreate(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *S
 	// rule) and treat as an error, if the ruleset is frontier we must
 	// ignore this error and pretend the operation was successful.
 	if suberr != nil {
-		stack.push(evm.interpreter.intPool.getZero())
+		stack.push(new(Word))
 	} else {
-		stack.push(AddressToBig(addr))
+		stack.push(AddressToWord(addr))
 	}
-	evm.interpreter.intPool.put(value, offset, size)
 
 	if suberr == errExecutionReverted {
 		return res, nil
@@ -642,108 +472,94 @@ func opCreate(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *S
 }
 
 func opCall(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	evm.interpreter.intPool.put(stack.pop())
+	stack.pop()
 	// Pop other call parameters.
 	addr, value, inOffset, inSize, retOffset, retSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
-	toAddr := BytesToAddress(addr.Bytes())
-	value = math.U256(value)
+	toAddr := WordToAddress(&addr)
 	// Get the arguments from the memory.
 	args := memory.Get(inOffset.Int64(), inSize.Int64())
 
 	ret, err := evm.Call(contract, toAddr, args, amount.NewAmountFromBytes(value.Bytes()))
 	if err != nil {
-		stack.push(evm.interpreter.intPool.getZero())
+		stack.push(new(Word))
 	} else {
-		stack.push(evm.interpreter.intPool.get().SetUint64(1))
+		stack.push(new(Word).SetUint64(1))
 	}
 	if err == nil || err == errExecutionReverted {
 		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
 	}
-
-	evm.interpreter.intPool.put(addr, value, inOffset, inSize, retOffset, retSize)
 	return ret, nil
 }
 
 func opCallCode(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	evm.interpreter.intPool.put(stack.pop())
+	stack.pop()
 	// Pop other call parameters.
 	addr, value, inOffset, inSize, retOffset, retSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
-	toAddr := BytesToAddress(addr.Bytes())
-	value = math.U256(value)
+	toAddr := WordToAddress(&addr)
 	// Get arguments from the memory.
 	args := memory.Get(inOffset.Int64(), inSize.Int64())
 
 	ret, err := evm.CallCode(contract, toAddr, args, amount.NewAmountFromBytes(value.Bytes()))
 	if err != nil {
-		stack.push(evm.interpreter.intPool.getZero())
+		stack.push(new(Word))
 	} else {
-		stack.push(evm.interpreter.intPool.get().SetUint64(1))
+		stack.push(new(Word).SetUint64(1))
 	}
 	if err == nil || err == errExecutionReverted {
 		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
 	}
-
-	evm.interpreter.intPool.put(addr, value, inOffset, inSize, retOffset, retSize)
 	return ret, nil
 }
 
 func opDelegateCall(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	evm.interpreter.intPool.put(stack.pop())
+	stack.pop()
 	// Pop other call parameters.
 	addr, inOffset, inSize, retOffset, retSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
-	toAddr := BytesToAddress(addr.Bytes())
+	toAddr := WordToAddress(&addr)
 	// Get arguments from the memory.
 	args := memory.Get(inOffset.Int64(), inSize.Int64())
 
 	ret, err := evm.DelegateCall(contract, toAddr, args)
 	if err != nil {
-		stack.push(evm.interpreter.intPool.getZero())
+		stack.push(new(Word))
 	} else {
-		stack.push(evm.interpreter.intPool.get().SetUint64(1))
+		stack.push(new(Word).SetUint64(1))
 	}
 	if err == nil || err == errExecutionReverted {
 		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
 	}
-
-	evm.interpreter.intPool.put(addr, inOffset, inSize, retOffset, retSize)
 	return ret, nil
 }
 
 func opStaticCall(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	evm.interpreter.intPool.put(stack.pop())
+	stack.pop()
 	// Pop other call parameters.
 	addr, inOffset, inSize, retOffset, retSize := stack.pop(), stack.pop(), stack.pop(), stack.pop(), stack.pop()
-	toAddr := BytesToAddress(addr.Bytes())
+	toAddr := WordToAddress(&addr)
 	// Get arguments from the memory.
 	args := memory.Get(inOffset.Int64(), inSize.Int64())
 
 	ret, err := evm.StaticCall(contract, toAddr, args)
 	if err != nil {
-		stack.push(evm.interpreter.intPool.getZero())
+		stack.push(new(Word))
 	} else {
-		stack.push(evm.interpreter.intPool.get().SetUint64(1))
+		stack.push(new(Word).SetUint64(1))
 	}
 	if err == nil || err == errExecutionReverted {
 		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
 	}
-
-	evm.interpreter.intPool.put(addr, inOffset, inSize, retOffset, retSize)
 	return ret, nil
 }
 
 func opReturn(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	offset, size := stack.pop(), stack.pop()
 	ret := memory.GetPtr(offset.Int64(), size.Int64())
-
-	evm.interpreter.intPool.put(offset, size)
 	return ret, nil
 }
 
 func opRevert(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	offset, size := stack.pop(), stack.pop()
 	ret := memory.GetPtr(offset.Int64(), size.Int64())
-
-	evm.interpreter.intPool.put(offset, size)
 	return ret, nil
 }
 
@@ -752,8 +568,10 @@ func opStop(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Sta
 }
 
 func opSuicide(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	balance := evm.StateDB.GetBalance(contract.Address())
-	evm.StateDB.AddBalance(BytesToAddress(stack.pop().Bytes()), balance)
+	beneficiary := stack.pop()
+	balance := evm.StateDB.GetBalance(contract.Address()).Clone()
+	evm.StateDB.SubBalance(contract.Address(), balance)
+	evm.StateDB.AddBalance(WordToAddress(&beneficiary), balance)
 
 	evm.StateDB.Suicide(contract.Address())
 	return nil, nil
@@ -767,21 +585,12 @@ func makeLog(size int) executionFunc {
 		topics := make([]hash.Hash256, size)
 		mStart, mSize := stack.pop(), stack.pop()
 		for i := 0; i < size; i++ {
-			topics[i] = BytesToHash(stack.pop().Bytes())
+			topic := stack.pop()
+			topics[i] = BytesToHash(topic.Bytes())
 		}
 
 		d := memory.Get(mStart.Int64(), mSize.Int64())
-		evm.StateDB.AddLog(&Log{
-			Address: contract.Address(),
-			Topics:  topics,
-			Data:    d,
-			// This is a non-consensus field, but assigned here because
-			// core/state doesn't know the current block number.
-			BlockNumber: evm.BlockNumber.Uint64(),
-		})
-
-		evm.interpreter.intPool.put(mStart, mSize)
-		return nil, nil
+		return nil, evm.addLog(contract.Address(), topics, d)
 	}
 }
 
@@ -800,8 +609,7 @@ func makePush(size uint64, pushByteSize int) executionFunc {
 			endMin = startMin + pushByteSize
 		}
 
-		integer := evm.interpreter.intPool.get()
-		stack.push(integer.SetBytes(RightPadBytes(contract.Code[startMin:endMin], pushByteSize)))
+		stack.push(new(Word).SetBytes(RightPadBytes(contract.Code[startMin:endMin], pushByteSize)))
 
 		*pc += size
 		return nil, nil
@@ -811,7 +619,7 @@ func makePush(size uint64, pushByteSize int) executionFunc {
 // make dup instruction function
 func makeDup(size int64) executionFunc {
 	return func(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-		stack.dup(evm.interpreter.intPool, int(size))
+		stack.dup(int(size))
 		return nil, nil
 	}
 }
@@ -44,6 +44,10 @@ type StateDB interface {
 	Suicide(common.Address) bool
 	HasSuicided(common.Address) bool
 
+	// PayRent charges the storage rent of the given contract and
+	// returns an error when the contract cannot be accessed.
+	PayRent(common.Address) error
+
 	// Exist reports whether the given account exists in state.
 	// Notably this should also return true for suicided accounts.
 	Exist(common.Address) bool
@@ -38,6 +38,35 @@ type Config struct {
 	// may be left uninitialised and will be set to the default
 	// table.
 	JumpTable [256]operation
+
+	// MaxMemorySize is the maximum total memory size of the active call
+	// frames of a transaction in bytes (0 means unlimited)
+	MaxMemorySize uint64
+	// MaxReturnDataSize is the maximum size of the data returned by a
+	// contract call or a precompiled contract call in bytes (0 means unlimited)
+	MaxReturnDataSize uint64
+	// MaxLogCount is the maximum number of logs per transaction
+	// (0 means unlimited)
+	MaxLogCount int
+	// MaxLogDataSize is the maximum total size of the log data per
+	// transaction in bytes (0 means unlimited)
+	MaxLogDataSize uint64
+	// MaxStorageWrites is the maximum number of storage writes per
+	// transaction (0 means unlimited)
+	MaxStorageWrites int
+	// MaxSteps is the maximum number of the executed opcodes per
+	// transaction, it bounds the execution deterministically (0 means unlimited)
+	MaxSteps uint64
+	// CodePolicy validates the code which is deployed by the Create
+	// (nil means the CodePolicy of the Rules)
+	CodePolicy *CodePolicy
+	// Rules are the semantics of the fork which selects the instruction set,
+	// the builtin precompiled contracts and the code size limit
+	// (nil means the rules of the LatestFork)
+	Rules *Rules
+	// Precompiles are the precompiled contracts of the EVM, it is copied by the NewEVM
+	// (nil means the builtin precompiled contracts of the fork of the Rules)
+	Precompiles *PrecompileSet
 }
 
 // Interpreter is used to run Ethereum based contracts and will utilise the
@@ -45,9 +74,8 @@ type Config struct {
 // The Interpreter will run the byte code VM based on the passed
 // configuration.
 type Interpreter struct {
-	evm     *EVM
-	cfg     Config
-	intPool *intPool
+	evm *EVM
+	cfg Config
 
 	readOnly   bool   // Whether to throw on stateful modifications
 	returnData []byte // Last CALL's return data for subsequent reuse
@@ -59,13 +87,12 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 	// the jump table was initialised. If it was not
 	// we'll set the default jump table.
 	if !cfg.JumpTable[STOP].valid {
-		cfg.JumpTable = constantinopleInstructionSet
+		cfg.JumpTable = evm.rules.instructionSet()
 	}
 
 	return &Interpreter{
-		evm:     evm,
-		cfg:     cfg,
-		intPool: newIntPool(),
+		evm: evm,
+		cfg: cfg,
 	}
 }
 
@@ -76,7 +103,7 @@ func (in *Interpreter) enforceRestrictions(op OpCode, operation operation, stack
 		// for a call operation is the value. Transferring value from one
 		// account to the others means the state is modified and should also
 		// return with an error.
-		if operation.writes || (op == CALL && stack.Back(2).BitLen() > 0) {
+		if operation.writes || (op == CALL && !stack.Back(2).IsZero()) {
 			return errWriteProtection
 		}
 	}
@@ -116,6 +143,8 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 		logged bool   // deferred Tracer should ignore already logged steps
 	)
 	contract.Input = input
+	// the memory of the frame is released when it returns
+	defer func() { in.evm.memorySize -= uint64(mem.Len()) }()
 
 	if in.cfg.Debug {
 		defer func() {
@@ -140,6 +169,10 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 
 		// Get the operation from the jump table and validate the stack to ensure there are
 		// enough stack items available to perform the operation.
+		in.evm.steps++
+		if in.cfg.MaxSteps > 0 && in.evm.steps > in.cfg.MaxSteps {
+			return nil, ErrStepLimitExceeded
+		}
 		op = contract.GetOp(pc)
 		operation := in.cfg.JumpTable[op]
 		if !operation.valid {
@@ -157,7 +190,7 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 		// calculate the new memory size and expand the memory to fit
 		// the operation
 		if operation.memorySize != nil {
-			memSize, overflow := bigUint64(operation.memorySize(stack))
+			memSize, overflow := operation.memorySize(stack)
 			if overflow {
 				return nil, errUintOverflow
 			}
@@ -166,8 +199,14 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 				return nil, errUintOverflow
 			}
 		}
-		if memorySize > 0 {
+		if memorySize > uint64(mem.Len()) {
+			// the memory of the active frames of the caller is counted together
+			growth := memorySize - uint64(mem.Len())
+			if in.cfg.MaxMemorySize > 0 && in.evm.memorySize+growth > in.cfg.MaxMemorySize {
+				return nil, ErrMemoryLimitExceeded
+			}
 			mem.Resize(memorySize)
+			in.evm.memorySize += growth
 		}
 
 		if in.cfg.Debug {
@@ -177,11 +216,6 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 
 		// execute the operation
 		res, err := operation.execute(&pc, in.evm, contract, mem, stack)
-		// verifyPool is a build flag. Pool verification makes sure the integrity
-		// of the integer pool by comparing values to a default value.
-		if verifyPool {
-			verifyIntegerPool(in.intPool)
-		}
 		// if the operation clears the return data (e.g. it has returning data)
 		// set the last return to the result of the operation.
 		if operation.returns {
@@ -199,5 +233,5 @@ func (in *Interpreter) Run(contract *Contract, input []byte) (ret []byte, err er
 			pc++
 		}
 	}
-	return nil, nil
+	return nil, ErrExecutionAborted
 }
@@ -0,0 +1,117 @@
+package vm
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/solidity/vm/hexutil"
+)
+
+// jsonStep is a step of the EIP-3155 trace
+// The gas fields are always zero because the EVM doesn't meter the gas
+type jsonStep struct {
+	Pc         uint64        `json:"pc"`
+	Op         uint8         `json:"op"`
+	Gas        string        `json:"gas"`
+	GasCost    string        `json:"gasCost"`
+	Memory     hexutil.Bytes `json:"memory,omitempty"`
+	MemorySize int           `json:"memSize"`
+	Stack      []string      `json:"stack"`
+	ReturnData hexutil.Bytes `json:"returnData,omitempty"`
+	Depth      int           `json:"depth"`
+	Refund     uint64        `json:"refund"`
+	OpName     string        `json:"opName"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// jsonSummary is the summary line of the EIP-3155 trace
+type jsonSummary struct {
+	Output  hexutil.Bytes `json:"output"`
+	GasUsed string        `json:"gasUsed"`
+	Time    int64         `json:"time"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// JSONLogger is a Tracer which streams one JSON object per step to the writer in the EIP-3155 format
+// and a summary object at the end of the execution
+// Unlike StructLogger, it doesn't keep the steps in memory
+type JSONLogger struct {
+	encoder *json.Encoder
+	cfg     LogConfig
+}
+
+// NewJSONLogger returns a JSONLogger which writes to the writer
+// The memory and the stack are omitted by the DisableMemory and the DisableStack of the cfg
+func NewJSONLogger(cfg *LogConfig, writer io.Writer) *JSONLogger {
+	l := &JSONLogger{
+		encoder: json.NewEncoder(writer),
+	}
+	if cfg != nil {
+		l.cfg = *cfg
+	}
+	return l
+}
+
+// CaptureStart doesn't work
+func (l *JSONLogger) CaptureStart(from common.Address, to common.Address, create bool, input []byte, value *amount.Amount) error {
+	return nil
+}
+
+// CaptureState writes the step of the execution
+func (l *JSONLogger) CaptureState(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	step := &jsonStep{
+		Pc:         pc,
+		Op:         uint8(op),
+		Gas:        "0x0",
+		GasCost:    "0x0",
+		MemorySize: memory.Len(),
+		Stack:      []string{},
+		ReturnData: env.interpreter.returnData,
+		Depth:      depth,
+		OpName:     op.String(),
+	}
+	if !l.cfg.DisableMemory {
+		step.Memory = memory.Data()
+	}
+	if !l.cfg.DisableStack {
+		for i := range stack.data {
+			step.Stack = append(step.Stack, stack.data[i].Hex())
+		}
+	}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	return l.encoder.Encode(step)
+}
+
+// CaptureFault writes the failed step again with the error
+// The step is written by the CaptureState before the operation is executed, so it doesn't have the error of the operation
+func (l *JSONLogger) CaptureFault(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return l.CaptureState(env, pc, op, memory, stack, contract, depth, err)
+}
+
+// CaptureEnd writes the summary of the execution
+func (l *JSONLogger) CaptureEnd(output []byte, t time.Duration, err error) error {
+	summary := &jsonSummary{
+		Output:  output,
+		GasUsed: "0x0",
+		Time:    int64(t),
+	}
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	return l.encoder.Encode(summary)
+}
+
+// CaptureEnter doesn't work
+func (l *JSONLogger) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, value *amount.Amount) error {
+	return nil
+}
+
+// CaptureExit doesn't work
+func (l *JSONLogger) CaptureExit(output []byte, err error) error {
+	return nil
+}
@@ -0,0 +1,45 @@
+package vm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fletaio/core/amount"
+)
+
+func TestJSONLoggerFault(t *testing.T) {
+	st := newTestState()
+	caller, contract := testAddress(1), testAddress(2)
+	st.CreateAccount(caller, "")
+	// the JUMP to 0x10 fails because it is not a JUMPDEST
+	st.deploy(contract, mustAssemble(t, "PUSH1 0x10\nJUMP"))
+
+	var buf bytes.Buffer
+	logger := NewJSONLogger(nil, &buf)
+	evm := newTestEVM(st, Config{Debug: true, Tracer: logger})
+	if _, err := evm.Call(AccountRef(caller), contract, nil, amount.NewCoinAmount(0, 0)); err == nil {
+		t.Fatal("the invalid jump succeeded")
+	}
+
+	var steps []map[string]interface{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var m map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		steps = append(steps, m)
+	}
+	if len(steps) < 2 {
+		t.Fatalf("got %d lines", len(steps))
+	}
+	fault := steps[len(steps)-2]
+	if fault["opName"] != "JUMP" || fault["error"] == nil || fault["error"] == "" {
+		t.Fatalf("the failed step doesn't have the error: %v", fault)
+	}
+	if summary := steps[len(steps)-1]; summary["error"] == nil {
+		t.Fatalf("the summary doesn't have the error: %v", summary)
+	}
+}
@@ -18,13 +18,12 @@ package vm
 
 import (
 	"errors"
-	"math/big"
 )
 
 type (
 	executionFunc       func(pc *uint64, env *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error)
 	stackValidationFunc func(*Stack) error
-	memorySizeFunc      func(*Stack) *big.Int
+	memorySizeFunc      func(*Stack) (uint64, bool)
 )
 
 var errUintOverflow = errors.New("uint64 overflow")
@@ -809,16 +808,16 @@ func NewFrontierInstructionSet() [256]operation {
 }
 
 func opGasprice(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(big.NewInt(0))
+	stack.push(new(Word))
 	return nil, nil
 }
 
 func opGasLimit(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(big.NewInt(300000))
+	stack.push(new(Word).SetUint64(300000))
 	return nil, nil
 }
 
 func opGas(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
-	stack.push(big.NewInt(300000))
+	stack.push(new(Word).SetUint64(300000))
 	return nil, nil
 }
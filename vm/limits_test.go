@@ -0,0 +1,184 @@
+package vm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/amount"
+)
+
+// callSource returns the assembly which calls the address with the inSize bytes of the memory
+// and leaves the success flag on the stack
+func callSource(addr common.Address, inSize int) string {
+	return fmt.Sprintf(`
+		PUSH1 0
+		PUSH1 0
+		PUSH1 %d
+		PUSH1 0
+		PUSH1 0
+		%s
+		PUSH1 0
+		CALL
+	`, inSize, pushAddress(addr))
+}
+
+// returnTopSource returns the assembly which returns the top of the stack as a word
+const returnTopSource = `
+	PUSH1 0
+	MSTORE
+	PUSH1 32
+	PUSH1 0
+	RETURN
+`
+
+func callLimited(t *testing.T, st *testState, cfg Config, addr common.Address, input []byte) ([]byte, error) {
+	t.Helper()
+	caller := testAddress(1)
+	st.CreateAccount(caller, "")
+	evm := newTestEVM(st, cfg)
+	return evm.Call(AccountRef(caller), addr, input, amount.NewCoinAmount(0, 0))
+}
+
+func TestMemoryLimitPerTransaction(t *testing.T) {
+	st := newTestState()
+	inner, outer := testAddress(2), testAddress(3)
+	// each frame expands the memory to 0x420 bytes
+	expand := `
+		PUSH1 1
+		PUSH2 0x0400
+		MSTORE
+	`
+	st.deploy(inner, mustAssemble(t, expand+"STOP"))
+	st.deploy(outer, mustAssemble(t, expand+callSource(inner, 0)+callSource(inner, 0)+"ADD"+returnTopSource))
+
+	tests := []struct {
+		limit uint64
+		calls uint64
+		err   error
+	}{
+		{0, 2, nil},
+		// the memory of the first inner frame is released before the second call
+		{2 * 0x420, 2, nil},
+		// the outer frame fits but the inner frames don't fit with it
+		{2*0x420 - 32, 0, nil},
+		{0x420 - 32, 0, ErrMemoryLimitExceeded},
+	}
+	for _, tt := range tests {
+		ret, err := callLimited(t, st, Config{MaxMemorySize: tt.limit}, outer, nil)
+		if err != tt.err {
+			t.Fatalf("limit %d: got error %v, want %v", tt.limit, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+		if got := new(Word).SetBytes(ret).Uint64(); got != tt.calls {
+			t.Fatalf("limit %d: got %d successful calls, want %d", tt.limit, got, tt.calls)
+		}
+	}
+}
+
+func TestReturnDataLimit(t *testing.T) {
+	st := newTestState()
+	returner, reverter, caller := testAddress(2), testAddress(3), testAddress(4)
+	st.deploy(returner, mustAssemble(t, "PUSH1 64\nPUSH1 0\nRETURN"))
+	st.deploy(reverter, mustAssemble(t, "PUSH1 64\nPUSH1 0\nREVERT"))
+	sha256Addr := builtinAddress(2)
+	st.deploy(caller, mustAssemble(t, callSource(sha256Addr, 4)+returnTopSource))
+
+	cfg := Config{MaxReturnDataSize: 63}
+	if _, err := callLimited(t, st, cfg, returner, nil); err != ErrReturnDataLimitExceeded {
+		t.Fatalf("RETURN: got error %v, want %v", err, ErrReturnDataLimitExceeded)
+	}
+	if _, err := callLimited(t, st, cfg, reverter, nil); err != ErrReturnDataLimitExceeded {
+		t.Fatalf("REVERT: got error %v, want %v", err, ErrReturnDataLimitExceeded)
+	}
+	if ret, err := callLimited(t, st, Config{MaxReturnDataSize: 64}, returner, nil); err != nil || len(ret) != 64 {
+		t.Fatalf("RETURN within the limit: got %d bytes, error %v", len(ret), err)
+	}
+
+	// the output of the precompiled contract is limited too
+	cfg = Config{MaxReturnDataSize: 31}
+	if _, err := callLimited(t, st, cfg, sha256Addr, []byte("data")); err != ErrReturnDataLimitExceeded {
+		t.Fatalf("precompile: got error %v, want %v", err, ErrReturnDataLimitExceeded)
+	}
+	cfg = Config{MaxReturnDataSize: 32}
+	ret, err := callLimited(t, st, cfg, caller, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(Word).SetBytes(ret).Uint64() != 1 {
+		t.Fatal("the precompile call within the limit failed")
+	}
+}
+
+func TestLogLimits(t *testing.T) {
+	st := newTestState()
+	logger := testAddress(2)
+	logSource := "PUSH1 8\nPUSH1 0\nLOG0\n"
+	st.deploy(logger, mustAssemble(t, logSource+logSource+logSource+"STOP"))
+
+	tests := []struct {
+		cfg Config
+		err error
+	}{
+		{Config{}, nil},
+		{Config{MaxLogCount: 3, MaxLogDataSize: 24}, nil},
+		{Config{MaxLogCount: 2}, ErrLogLimitExceeded},
+		{Config{MaxLogDataSize: 16}, ErrLogDataLimitExceeded},
+	}
+	for i, tt := range tests {
+		st.logs = nil
+		if _, err := callLimited(t, st, tt.cfg, logger, nil); err != tt.err {
+			t.Fatalf("%d: got error %v, want %v", i, err, tt.err)
+		}
+		if tt.err == nil && len(st.logs) != 3 {
+			t.Fatalf("%d: got %d logs, want 3", i, len(st.logs))
+		}
+	}
+}
+
+func TestStorageWriteLimit(t *testing.T) {
+	st := newTestState()
+	writer := testAddress(2)
+	st.deploy(writer, mustAssemble(t, `
+		PUSH1 1
+		PUSH1 1
+		SSTORE
+		PUSH1 2
+		PUSH1 2
+		SSTORE
+		PUSH1 3
+		PUSH1 3
+		SSTORE
+	`))
+
+	if _, err := callLimited(t, st, Config{MaxStorageWrites: 3}, writer, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callLimited(t, st, Config{MaxStorageWrites: 2}, writer, nil); err != ErrStorageWriteLimitExceeded {
+		t.Fatalf("got error %v, want %v", err, ErrStorageWriteLimitExceeded)
+	}
+}
+
+func TestStepLimit(t *testing.T) {
+	st := newTestState()
+	looper, counter := testAddress(2), testAddress(3)
+	st.deploy(looper, mustAssemble(t, `
+		loop:
+		JUMPDEST
+		JUMP loop
+	`))
+	// PUSH1, PUSH1, ADD and STOP
+	st.deploy(counter, mustAssemble(t, "PUSH1 1\nPUSH1 2\nADD\nSTOP"))
+
+	if _, err := callLimited(t, st, Config{MaxSteps: 1000}, looper, nil); err != ErrStepLimitExceeded {
+		t.Fatalf("got error %v, want %v", err, ErrStepLimitExceeded)
+	}
+	if _, err := callLimited(t, st, Config{MaxSteps: 4}, counter, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callLimited(t, st, Config{MaxSteps: 3}, counter, nil); err != ErrStepLimitExceeded {
+		t.Fatalf("got error %v, want %v", err, ErrStepLimitExceeded)
+	}
+}
@@ -86,7 +86,8 @@ func (s *StructLog) ErrorString() string {
 
 // Tracer is used to collect execution traces from an EVM transaction
 // execution. CaptureState is called for each step of the VM with the
-// current VM state.
+// current VM state. CaptureStart and CaptureEnd are called for the top
+// level call, CaptureEnter and CaptureExit for each internal call frame.
 // Note that reference types are actual VM data structures; make copies
 // if you need to retain them beyond the current call.
 type Tracer interface {
@@ -94,6 +95,15 @@ type Tracer interface {
 	CaptureState(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error
 	CaptureFault(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error
 	CaptureEnd(output []byte, t time.Duration, err error) error
+	CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, value *amount.Amount) error
+	CaptureExit(output []byte, err error) error
+}
+
+// StateDBTracer is a Tracer which also observes the accesses to the StateDB
+// The EVM in debug mode executes on the StateDB which is wrapped by WrapStateDB
+type StateDBTracer interface {
+	Tracer
+	WrapStateDB(db StateDB) StateDB
 }
 
 // StructLogger is an EVM state logger and implements Tracer.
@@ -160,7 +170,7 @@ func (l *StructLogger) CaptureState(env *EVM, pc uint64, op OpCode, memory *Memo
 	if !l.cfg.DisableStack {
 		stck = make([]*big.Int, len(stack.Data()))
 		for i, item := range stack.Data() {
-			stck[i] = new(big.Int).Set(item)
+			stck[i] = item.ToBig()
 		}
 	}
 	// Copy a snapshot of the current storage to a new container
@@ -191,6 +201,14 @@ func (l *StructLogger) CaptureEnd(output []byte, t time.Duration, err error) err
 	return nil
 }
 
+func (l *StructLogger) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, value *amount.Amount) error {
+	return nil
+}
+
+func (l *StructLogger) CaptureExit(output []byte, err error) error {
+	return nil
+}
+
 // StructLogs returns the captured log entries.
 func (l *StructLogger) StructLogs() []StructLog { return l.logs }
 
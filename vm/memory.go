@@ -18,9 +18,6 @@ package vm
 
 import (
 	"fmt"
-	"math/big"
-
-	"github.com/fletaio/solidity/vm/math"
 )
 
 // Memory implements a simple memory model for the ethereum virtual machine.
@@ -48,16 +45,14 @@ func (m *Memory) Set(offset, size uint64, value []byte) {
 
 // Set32 sets the 32 bytes starting at offset to the value of val, left-padded with zeroes to
 // 32 bytes.
-func (m *Memory) Set32(offset uint64, val *big.Int) {
+func (m *Memory) Set32(offset uint64, val *Word) {
 	// length of store may never be less than offset + size.
 	// The store should be resized PRIOR to setting the memory
 	if offset+32 > uint64(len(m.store)) {
 		panic("invalid memory: store empty")
 	}
-	// Zero the memory area
-	copy(m.store[offset:offset+32], []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
-	// Fill in relevant bits
-	math.ReadBits(val, m.store[offset:offset+32])
+	bs := val.Bytes32()
+	copy(m.store[offset:offset+32], bs[:])
 }
 
 // Resize resizes the memory to size
@@ -58,6 +58,7 @@ func (NoopStateDB) GetState(common.Address, hash.Hash256) hash.Hash256  { return
 func (NoopStateDB) SetState(common.Address, hash.Hash256, hash.Hash256) {}
 func (NoopStateDB) Suicide(common.Address) bool                         { return false }
 func (NoopStateDB) HasSuicided(common.Address) bool                     { return false }
+func (NoopStateDB) PayRent(common.Address) error                        { return nil }
 func (NoopStateDB) Exist(common.Address) bool                           { return false }
 func (NoopStateDB) Empty(common.Address) bool                           { return false }
 func (NoopStateDB) RevertToSnapshot(int)                                {}
@@ -0,0 +1,91 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/fletaio/common"
+)
+
+// The addresses of the builtin precompiled contracts are the addresses of the words 0x01 to 0xff
+// and the addresses of the chain specific precompiled contracts are CustomPrecompileAddress(n)
+// which are the reserved addresses of the nonce 0x0001 to 0xffff, so they never collide with the accounts
+// and are called by the bytecode like the other addresses
+
+// CustomPrecompileAddress returns the address of the n-th chain specific precompiled contract
+// The n should not be 0
+func CustomPrecompileAddress(n uint16) common.Address {
+	return ReservedAddress(uint64(n))
+}
+
+// IsCustomPrecompileAddress returns true when the address is reserved for the chain specific precompiled contracts
+func IsCustomPrecompileAddress(addr common.Address) bool {
+	if !IsReservedAddress(addr) {
+		return false
+	}
+	nonce := binary.BigEndian.Uint64(addr[common.AddressSize-8:])
+	return nonce >= 1 && nonce <= 0xFFFF
+}
+
+// PrecompileSet is the set of the precompiled contracts of an EVM
+type PrecompileSet struct {
+	contracts map[common.Address]PrecompiledContract
+}
+
+// NewPrecompileSet returns the set of the builtin precompiled contracts of the fork
+func NewPrecompileSet(f Fork) *PrecompileSet {
+	builtin := PrecompiledContractsHomestead
+	if f >= Byzantium {
+		builtin = PrecompiledContractsByzantium
+	}
+	s := &PrecompileSet{
+		contracts: make(map[common.Address]PrecompiledContract, len(builtin)),
+	}
+	for addr, p := range builtin {
+		s.contracts[addr] = p
+	}
+	return s
+}
+
+// Register adds the chain specific precompiled contract of the address
+// The address should be a CustomPrecompileAddress (the reserved address of the nonce 0x0001 to 0xffff)
+func (s *PrecompileSet) Register(addr common.Address, p PrecompiledContract) error {
+	if !IsCustomPrecompileAddress(addr) {
+		return ErrReservedPrecompileAddress
+	}
+	if _, has := s.contracts[addr]; has {
+		return ErrExistPrecompile
+	}
+	s.contracts[addr] = p
+	return nil
+}
+
+// Get returns the precompiled contract of the address or nil
+func (s *PrecompileSet) Get(addr common.Address) PrecompiledContract {
+	return s.contracts[addr]
+}
+
+// Addresses returns the addresses of the precompiled contracts in ascending order
+func (s *PrecompileSet) Addresses() []common.Address {
+	list := make([]common.Address, 0, len(s.contracts))
+	for addr := range s.contracts {
+		list = append(list, addr)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i][:], list[j][:]) < 0
+	})
+	return list
+}
+
+// Clone returns the copy of the set
+// The registration to the copy doesn't affect the original set
+func (s *PrecompileSet) Clone() *PrecompileSet {
+	c := &PrecompileSet{
+		contracts: make(map[common.Address]PrecompiledContract, len(s.contracts)),
+	}
+	for addr, p := range s.contracts {
+		c.contracts[addr] = p
+	}
+	return c
+}
@@ -0,0 +1,145 @@
+package vm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/core/amount"
+)
+
+// incrementer is a precompiled contract which returns the input word plus one
+type incrementer struct{}
+
+func (p *incrementer) Run(input []byte) ([]byte, error) {
+	ret := new(Word).Add(new(Word).SetBytes(input), new(Word).SetUint64(1)).Bytes32()
+	return ret[:], nil
+}
+
+func TestCustomPrecompileAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		addr   common.Address
+		custom bool
+	}{
+		{"n=1", CustomPrecompileAddress(1), true},
+		{"n=0xffff", CustomPrecompileAddress(0xFFFF), true},
+		{"nonce 0", ReservedAddress(0), false},
+		{"nonce 0x10000", ReservedAddress(0x10000), false},
+		{"builtin", builtinAddress(2), false},
+		{"account", testAddress(1), false},
+		{"coordinate", BytesToAddress([]byte{0x00, 0x00, 0x01}), false},
+	}
+	for _, tt := range tests {
+		if got := IsCustomPrecompileAddress(tt.addr); got != tt.custom {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.custom)
+		}
+	}
+	if err := NewPrecompileSet(LatestFork).Register(BytesToAddress([]byte{0x00, 0x00, 0x01}), &incrementer{}); err != ErrReservedPrecompileAddress {
+		t.Fatalf("got error %v, want %v", err, ErrReservedPrecompileAddress)
+	}
+}
+
+func TestCallCustomPrecompile(t *testing.T) {
+	addr := CustomPrecompileAddress(1)
+	set := NewPrecompileSet(LatestFork)
+	if err := set.Register(addr, &incrementer{}); err != nil {
+		t.Fatal(err)
+	}
+	st := newTestState()
+	caller := testAddress(2)
+	st.deploy(caller, mustAssemble(t, fmt.Sprintf(`
+		PUSH1 0x2a
+		PUSH1 0
+		MSTORE
+		PUSH1 32
+		PUSH1 0
+		PUSH1 32
+		PUSH1 0
+		PUSH1 0
+		%s
+		PUSH1 0
+		CALL
+		PUSH1 32
+		MSTORE
+		PUSH1 64
+		PUSH1 0
+		RETURN
+	`, pushAddress(addr))))
+
+	ret, err := callLimited(t, st, Config{Precompiles: set}, caller, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ret) != 64 {
+		t.Fatalf("got %d bytes, want 64", len(ret))
+	}
+	if ok := new(Word).SetBytes(ret[32:]).Uint64(); ok != 1 {
+		t.Fatal("the call of the precompiled contract failed")
+	}
+	if got := new(Word).SetBytes(ret[:32]).Uint64(); got != 0x2b {
+		t.Fatalf("got 0x%x, want 0x2b", got)
+	}
+
+	// the address is not a precompiled contract without the registration
+	ret, err = callLimited(t, st, Config{}, caller, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := new(Word).SetBytes(ret[:32]).Uint64(); got != 0x2a {
+		t.Fatalf("got 0x%x from the unregistered address, want 0x2a", got)
+	}
+}
+
+// payer is a stateful precompiled contract which transfers the value of the call to the address of the input
+type payer struct{}
+
+func (p *payer) Run(input []byte) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *payer) RunStateful(env *PrecompileEnv, input []byte) ([]byte, error) {
+	var to common.Address
+	copy(to[:], input)
+	return nil, env.Transfer(to, env.Value)
+}
+
+func TestPrecompileNotPayable(t *testing.T) {
+	st := newTestState()
+	caller := testAddress(1)
+	st.AddBalance(caller, amount.NewCoinAmount(10, 0))
+	one := amount.NewCoinAmount(1, 0)
+
+	// the name resolver and the sha256 don't have the accounts
+	for _, addr := range []common.Address{builtinAddress(0x10), builtinAddress(2)} {
+		evm := newTestEVM(st, Config{})
+		if _, err := evm.Call(AccountRef(caller), addr, nil, one); err != ErrPrecompileNotPayable {
+			t.Fatalf("%s: got error %v, want %v", addr.String(), err, ErrPrecompileNotPayable)
+		}
+		if _, err := evm.Call(AccountRef(caller), builtinAddress(2), nil, amount.NewCoinAmount(0, 0)); err != nil {
+			t.Fatalf("%s: the call without the value failed: %v", addr.String(), err)
+		}
+	}
+	if !st.GetBalance(caller).Equal(amount.NewCoinAmount(10, 0)) {
+		t.Fatalf("the balance of the caller is changed to %s", st.GetBalance(caller).String())
+	}
+
+	addr := CustomPrecompileAddress(1)
+	set := NewPrecompileSet(LatestFork)
+	if err := set.Register(addr, &payer{}); err != nil {
+		t.Fatal(err)
+	}
+	st.CreateAccount(addr, "")
+	receiver := testAddress(2)
+	evm := newTestEVM(st, Config{Precompiles: set})
+	if _, err := evm.Call(AccountRef(caller), addr, receiver[:], one); err != ErrNotExistAccount {
+		t.Fatalf("got error %v, want %v", err, ErrNotExistAccount)
+	}
+	st.CreateAccount(receiver, "")
+	if _, err := evm.Call(AccountRef(caller), addr, receiver[:], one); err != nil {
+		t.Fatal(err)
+	}
+	if !st.GetBalance(receiver).Equal(one) {
+		t.Fatalf("got the balance %s of the receiver, want %s", st.GetBalance(receiver).String(), one.String())
+	}
+}
@@ -0,0 +1,327 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+	"github.com/fletaio/solidity/vm/hexutil"
+)
+
+// PrestateAccount is the state of the account which is touched by the execution
+type PrestateAccount struct {
+	Balance *amount.Amount
+	Seq     uint64
+	Code    []byte
+	Storage map[hash.Hash256]hash.Hash256
+	Removed bool // true in the post-state when the account is removed by the execution
+}
+
+// MarshalJSON is a marshaler function
+func (acc *PrestateAccount) MarshalJSON() ([]byte, error) {
+	var storage map[string]hash.Hash256
+	if len(acc.Storage) > 0 {
+		storage = make(map[string]hash.Hash256, len(acc.Storage))
+		for k, v := range acc.Storage {
+			storage[k.String()] = v
+		}
+	}
+	return json.Marshal(&struct {
+		Balance *amount.Amount          `json:"balance,omitempty"`
+		Seq     uint64                  `json:"seq,omitempty"`
+		Code    hexutil.Bytes           `json:"code,omitempty"`
+		Storage map[string]hash.Hash256 `json:"storage,omitempty"`
+		Removed bool                    `json:"removed,omitempty"`
+	}{
+		Balance: acc.Balance,
+		Seq:     acc.Seq,
+		Code:    acc.Code,
+		Storage: storage,
+		Removed: acc.Removed,
+	})
+}
+
+// PrestateTracer is a StateDBTracer which records the accounts and the storage slots
+// accessed by the execution with their values before the execution
+// The accesses are recorded by the StateDB which is wrapped by WrapStateDB
+type PrestateTracer struct {
+	db       StateDB
+	diffMode bool
+	exists   map[common.Address]bool
+	pre      map[common.Address]*PrestateAccount
+	slots    map[common.Address]map[hash.Hash256]hash.Hash256
+	order    []common.Address
+}
+
+// NewPrestateTracer returns a PrestateTracer
+// The post-state diff is also reported when diffMode is true
+func NewPrestateTracer(diffMode bool) *PrestateTracer {
+	return &PrestateTracer{
+		diffMode: diffMode,
+		exists:   map[common.Address]bool{},
+		pre:      map[common.Address]*PrestateAccount{},
+		slots:    map[common.Address]map[hash.Hash256]hash.Hash256{},
+	}
+}
+
+// WrapStateDB returns the StateDB which records the accesses to the db
+func (t *PrestateTracer) WrapStateDB(db StateDB) StateDB {
+	t.db = db
+	return &prestateDB{StateDB: db, tracer: t}
+}
+
+// CaptureStart records the sender and the recipient
+func (t *PrestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, value *amount.Amount) error {
+	t.touch(from)
+	t.touch(to)
+	return nil
+}
+
+// CaptureState doesn't work
+func (t *PrestateTracer) CaptureState(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureFault doesn't work
+func (t *PrestateTracer) CaptureFault(env *EVM, pc uint64, op OpCode, memory *Memory, stack *Stack, contract *Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd doesn't work
+func (t *PrestateTracer) CaptureEnd(output []byte, d time.Duration, err error) error {
+	return nil
+}
+
+// CaptureEnter records the recipient of the internal call
+func (t *PrestateTracer) CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, value *amount.Amount) error {
+	t.touch(to)
+	return nil
+}
+
+// CaptureExit doesn't work
+func (t *PrestateTracer) CaptureExit(output []byte, err error) error {
+	return nil
+}
+
+// touch records the account of the address before the first access
+func (t *PrestateTracer) touch(addr common.Address) {
+	if _, has := t.exists[addr]; has {
+		return
+	}
+	exist := t.db.Exist(addr)
+	t.exists[addr] = exist
+	t.order = append(t.order, addr)
+	if !exist {
+		return
+	}
+	t.pre[addr] = &PrestateAccount{
+		Balance: t.db.GetBalance(addr).Clone(),
+		Seq:     t.db.GetSeq(addr),
+		Code:    CopyBytes(t.db.GetCode(addr)),
+		Storage: map[hash.Hash256]hash.Hash256{},
+	}
+}
+
+// touchSlot records the storage slot of the address before the first access
+func (t *PrestateTracer) touchSlot(addr common.Address, key hash.Hash256) {
+	t.touch(addr)
+	slots, has := t.slots[addr]
+	if !has {
+		slots = map[hash.Hash256]hash.Hash256{}
+		t.slots[addr] = slots
+	}
+	if _, has := slots[key]; has {
+		return
+	}
+	value := t.db.GetState(addr, key)
+	slots[key] = value
+	if acc, has := t.pre[addr]; has {
+		acc.Storage[key] = value
+	}
+}
+
+// Prestate returns the accessed accounts and storage slots with their values before the execution
+func (t *PrestateTracer) Prestate() map[common.Address]*PrestateAccount {
+	return t.pre
+}
+
+// PostState returns the accessed accounts and storage slots whose values are changed by the execution
+// It only contains the changed fields with their current values
+// The accounts which existed before the execution and are removed or self-destructed by it are reported as removed
+func (t *PrestateTracer) PostState() map[common.Address]*PrestateAccount {
+	post := map[common.Address]*PrestateAccount{}
+	for _, addr := range t.order {
+		if !t.db.Exist(addr) || t.db.HasSuicided(addr) {
+			if t.exists[addr] {
+				post[addr] = &PrestateAccount{Removed: true}
+			}
+			continue
+		}
+		pre, has := t.pre[addr]
+		if !has {
+			pre = &PrestateAccount{}
+		}
+		diff := &PrestateAccount{}
+		changed := false
+		if balance := t.db.GetBalance(addr); pre.Balance == nil || !pre.Balance.Equal(balance) {
+			diff.Balance = balance.Clone()
+			changed = true
+		}
+		if seq := t.db.GetSeq(addr); pre.Seq != seq {
+			diff.Seq = seq
+			changed = true
+		}
+		if code := t.db.GetCode(addr); !bytes.Equal(pre.Code, code) {
+			diff.Code = CopyBytes(code)
+			changed = true
+		}
+		for key, value := range t.slots[addr] {
+			if v := t.db.GetState(addr, key); v != value {
+				if diff.Storage == nil {
+					diff.Storage = map[hash.Hash256]hash.Hash256{}
+				}
+				diff.Storage[key] = v
+				changed = true
+			}
+		}
+		if changed {
+			post[addr] = diff
+		}
+	}
+	return post
+}
+
+// MarshalJSON is a marshaler function
+// It returns the pre-state, or the pre-state and the post-state diff in the diff mode
+func (t *PrestateTracer) MarshalJSON() ([]byte, error) {
+	pre := accountsToJSONMap(t.pre)
+	if !t.diffMode {
+		return json.Marshal(pre)
+	}
+	return json.Marshal(&struct {
+		Pre  map[string]*PrestateAccount `json:"pre"`
+		Post map[string]*PrestateAccount `json:"post"`
+	}{
+		Pre:  pre,
+		Post: accountsToJSONMap(t.PostState()),
+	})
+}
+
+func accountsToJSONMap(accs map[common.Address]*PrestateAccount) map[string]*PrestateAccount {
+	m := make(map[string]*PrestateAccount, len(accs))
+	for addr, acc := range accs {
+		m[addr.String()] = acc
+	}
+	return m
+}
+
+// prestateDB is a StateDB which records the accesses to the underlying StateDB by the PrestateTracer
+type prestateDB struct {
+	StateDB
+	tracer *PrestateTracer
+}
+
+// CreateAccount records the address and creates the account
+func (db *prestateDB) CreateAccount(addr common.Address, name string) {
+	db.tracer.touch(addr)
+	db.StateDB.CreateAccount(addr, name)
+}
+
+// SubBalance records the address and subtracts the balance
+func (db *prestateDB) SubBalance(addr common.Address, b *amount.Amount) {
+	db.tracer.touch(addr)
+	db.StateDB.SubBalance(addr, b)
+}
+
+// AddBalance records the address and adds the balance
+func (db *prestateDB) AddBalance(addr common.Address, b *amount.Amount) {
+	db.tracer.touch(addr)
+	db.StateDB.AddBalance(addr, b)
+}
+
+// GetBalance records the address and returns the balance
+func (db *prestateDB) GetBalance(addr common.Address) *amount.Amount {
+	db.tracer.touch(addr)
+	return db.StateDB.GetBalance(addr)
+}
+
+// GetSeq records the address and returns the sequence
+func (db *prestateDB) GetSeq(addr common.Address) uint64 {
+	db.tracer.touch(addr)
+	return db.StateDB.GetSeq(addr)
+}
+
+// AddSeq records the address and increases the sequence
+func (db *prestateDB) AddSeq(addr common.Address) {
+	db.tracer.touch(addr)
+	db.StateDB.AddSeq(addr)
+}
+
+// GetCodeHash records the address and returns the code hash
+func (db *prestateDB) GetCodeHash(addr common.Address) hash.Hash256 {
+	db.tracer.touch(addr)
+	return db.StateDB.GetCodeHash(addr)
+}
+
+// GetCode records the address and returns the code
+func (db *prestateDB) GetCode(addr common.Address) []byte {
+	db.tracer.touch(addr)
+	return db.StateDB.GetCode(addr)
+}
+
+// SetCode records the address and sets the code
+func (db *prestateDB) SetCode(addr common.Address, code []byte) {
+	db.tracer.touch(addr)
+	db.StateDB.SetCode(addr, code)
+}
+
+// GetCodeSize records the address and returns the code size
+func (db *prestateDB) GetCodeSize(addr common.Address) int {
+	db.tracer.touch(addr)
+	return db.StateDB.GetCodeSize(addr)
+}
+
+// GetState records the storage slot and returns the value
+func (db *prestateDB) GetState(addr common.Address, h hash.Hash256) hash.Hash256 {
+	db.tracer.touchSlot(addr, h)
+	return db.StateDB.GetState(addr, h)
+}
+
+// SetState records the storage slot and sets the value
+func (db *prestateDB) SetState(addr common.Address, h hash.Hash256, v hash.Hash256) {
+	db.tracer.touchSlot(addr, h)
+	db.StateDB.SetState(addr, h, v)
+}
+
+// Suicide records the address and marks the account as suicided
+func (db *prestateDB) Suicide(addr common.Address) bool {
+	db.tracer.touch(addr)
+	return db.StateDB.Suicide(addr)
+}
+
+// HasSuicided records the address and checks the dead state of the address
+func (db *prestateDB) HasSuicided(addr common.Address) bool {
+	db.tracer.touch(addr)
+	return db.StateDB.HasSuicided(addr)
+}
+
+// PayRent records the address and charges the rent
+func (db *prestateDB) PayRent(addr common.Address) error {
+	db.tracer.touch(addr)
+	return db.StateDB.PayRent(addr)
+}
+
+// Exist records the address and checks that the account of the address is exist or not
+func (db *prestateDB) Exist(addr common.Address) bool {
+	db.tracer.touch(addr)
+	return db.StateDB.Exist(addr)
+}
+
+// Empty records the address and checks that the account of the address is empty or not
+func (db *prestateDB) Empty(addr common.Address) bool {
+	db.tracer.touch(addr)
+	return db.StateDB.Empty(addr)
+}
@@ -0,0 +1,30 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/fletaio/core/amount"
+)
+
+func TestPrestateTracerRemovedAccount(t *testing.T) {
+	st := newTestState()
+	caller, victim, beneficiary := testAddress(1), testAddress(2), testAddress(3)
+	st.CreateAccount(caller, "")
+	st.deploy(victim, mustAssemble(t, pushAddress(beneficiary)+"\nSELFDESTRUCT"))
+
+	tracer := NewPrestateTracer(true)
+	evm := newTestEVM(st, Config{Debug: true, Tracer: tracer})
+	if _, err := evm.Call(AccountRef(caller), victim, nil, amount.NewCoinAmount(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if _, has := tracer.Prestate()[victim]; !has {
+		t.Fatal("the pre-state doesn't have the removed account")
+	}
+	acc, has := tracer.PostState()[victim]
+	if !has || !acc.Removed {
+		t.Fatalf("the post-state doesn't report the removed account: %+v", acc)
+	}
+	if acc, has := tracer.PostState()[caller]; has && acc.Removed {
+		t.Fatal("the caller is reported as removed")
+	}
+}
@@ -0,0 +1,227 @@
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+)
+
+// testAccount is an account of the testState
+type testAccount struct {
+	name     string
+	balance  *amount.Amount
+	seq      uint64
+	code     []byte
+	storage  map[hash.Hash256]hash.Hash256
+	suicided bool
+}
+
+func (acc *testAccount) clone() *testAccount {
+	c := *acc
+	c.balance = acc.balance.Clone()
+	c.storage = make(map[hash.Hash256]hash.Hash256, len(acc.storage))
+	for k, v := range acc.storage {
+		c.storage[k] = v
+	}
+	return &c
+}
+
+// testState is a StateDB which keeps the accounts in memory for the tests
+type testState struct {
+	accounts  map[common.Address]*testAccount
+	snapshots []map[common.Address]*testAccount
+	logs      []*Log
+}
+
+func newTestState() *testState {
+	return &testState{
+		accounts: map[common.Address]*testAccount{},
+	}
+}
+
+// deploy creates the account of the address with the code
+func (st *testState) deploy(addr common.Address, code []byte) {
+	st.CreateAccount(addr, "")
+	st.SetCode(addr, code)
+}
+
+func (st *testState) account(addr common.Address) *testAccount {
+	acc, has := st.accounts[addr]
+	if !has {
+		acc = &testAccount{
+			balance: amount.NewCoinAmount(0, 0),
+			storage: map[hash.Hash256]hash.Hash256{},
+		}
+		st.accounts[addr] = acc
+	}
+	return acc
+}
+
+func (st *testState) CreateAccount(addr common.Address, name string) {
+	st.account(addr).name = name
+}
+
+func (st *testState) SubBalance(addr common.Address, b *amount.Amount) {
+	acc := st.account(addr)
+	acc.balance = acc.balance.Sub(b)
+}
+
+func (st *testState) AddBalance(addr common.Address, b *amount.Amount) {
+	acc := st.account(addr)
+	acc.balance = acc.balance.Add(b)
+}
+
+func (st *testState) GetBalance(addr common.Address) *amount.Amount {
+	if acc, has := st.accounts[addr]; has {
+		return acc.balance.Clone()
+	}
+	return amount.NewCoinAmount(0, 0)
+}
+
+func (st *testState) GetSeq(addr common.Address) uint64 {
+	if acc, has := st.accounts[addr]; has {
+		return acc.seq
+	}
+	return 0
+}
+
+func (st *testState) AddSeq(addr common.Address) {
+	st.account(addr).seq++
+}
+
+func (st *testState) GetCodeHash(addr common.Address) hash.Hash256 {
+	if acc, has := st.accounts[addr]; has && len(acc.code) > 0 {
+		return hash.Hash(acc.code)
+	}
+	return hash.Hash256{}
+}
+
+func (st *testState) GetCode(addr common.Address) []byte {
+	if acc, has := st.accounts[addr]; has {
+		return acc.code
+	}
+	return nil
+}
+
+func (st *testState) SetCode(addr common.Address, code []byte) {
+	st.account(addr).code = code
+}
+
+func (st *testState) GetCodeSize(addr common.Address) int {
+	return len(st.GetCode(addr))
+}
+
+func (st *testState) GetState(addr common.Address, h hash.Hash256) hash.Hash256 {
+	if acc, has := st.accounts[addr]; has {
+		return acc.storage[h]
+	}
+	return hash.Hash256{}
+}
+
+func (st *testState) SetState(addr common.Address, h hash.Hash256, v hash.Hash256) {
+	acc := st.account(addr)
+	if v == (hash.Hash256{}) {
+		delete(acc.storage, h)
+	} else {
+		acc.storage[h] = v
+	}
+}
+
+func (st *testState) Suicide(addr common.Address) bool {
+	st.account(addr).suicided = true
+	return true
+}
+
+func (st *testState) HasSuicided(addr common.Address) bool {
+	acc, has := st.accounts[addr]
+	return has && acc.suicided
+}
+
+func (st *testState) PayRent(addr common.Address) error {
+	return nil
+}
+
+func (st *testState) Exist(addr common.Address) bool {
+	_, has := st.accounts[addr]
+	return has
+}
+
+func (st *testState) Empty(addr common.Address) bool {
+	return st.GetSeq(addr) == 0 && st.GetBalance(addr).IsZero() && st.GetCodeSize(addr) == 0
+}
+
+func (st *testState) RevertToSnapshot(n int) {
+	if n >= len(st.snapshots) {
+		return
+	}
+	st.accounts = st.snapshots[n]
+	st.snapshots = st.snapshots[:n]
+}
+
+func (st *testState) CommitSnapshot(n int) {
+	if n >= len(st.snapshots) {
+		return
+	}
+	st.snapshots = st.snapshots[:n]
+}
+
+func (st *testState) Snapshot() int {
+	accounts := make(map[common.Address]*testAccount, len(st.accounts))
+	for addr, acc := range st.accounts {
+		accounts[addr] = acc.clone()
+	}
+	st.snapshots = append(st.snapshots, accounts)
+	return len(st.snapshots) - 1
+}
+
+func (st *testState) AddLog(l *Log) {
+	st.logs = append(st.logs, l)
+}
+
+// newTestEVM returns the EVM of the state with the config
+func newTestEVM(st *testState, cfg Config) *EVM {
+	ctx := Context{
+		CanTransfer: func(db StateDB, addr common.Address, v *amount.Amount) bool {
+			return !db.GetBalance(addr).Less(v)
+		},
+		Transfer: func(db StateDB, from common.Address, to common.Address, v *amount.Amount) {
+			// the zero value doesn't touch the accounts like the Transfer of the chain
+			if !v.IsZero() {
+				db.SubBalance(from, v)
+				db.AddBalance(to, v)
+			}
+		},
+		GetHash: func(uint64) hash.Hash256 {
+			return hash.Hash256{}
+		},
+		BlockNumber: big.NewInt(1),
+		Time:        big.NewInt(1),
+		Difficulty:  big.NewInt(0),
+	}
+	return NewEVM(ctx, st, cfg)
+}
+
+// testAddress returns the address of the test account n
+// The first byte is not zero, so the address is not an address of the builtin precompiled contracts
+func testAddress(n byte) common.Address {
+	return common.Address{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0, 0, 0, 0, 0, 0, 0, n}
+}
+
+// pushAddress returns the assembly which pushes the address
+func pushAddress(addr common.Address) string {
+	return fmt.Sprintf("PUSH%d 0x%x", len(addr), addr[:])
+}
+
+// mustAssemble returns the bytecode of the assembly source
+func mustAssemble(t testing.TB, src string) []byte {
+	t.Helper()
+	code, err := Assemble(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
@@ -0,0 +1,83 @@
+package vm
+
+import (
+	"github.com/fletaio/common"
+	"github.com/fletaio/common/hash"
+	"github.com/fletaio/core/amount"
+)
+
+// StatefulPrecompiledContract is the precompiled contract which accesses the caller, the value and the state
+// The RunStateful is used instead of the Run when the contract is called by the EVM
+//
+// The state changes of the contract are reverted with the call when it returns an error,
+// and the changes should be made by the PrecompileEnv to respect the read-only mode of the STATICCALL
+type StatefulPrecompiledContract interface {
+	PrecompiledContract
+	RunStateful(env *PrecompileEnv, input []byte) ([]byte, error)
+}
+
+// PrecompileEnv is the environment of the call of the stateful precompiled contract
+type PrecompileEnv struct {
+	EVM      *EVM
+	Caller   common.Address
+	Address  common.Address // address of the storage, it is the caller's address in the DELEGATECALL and the CALLCODE
+	Value    *amount.Amount // the value is transferred to the Address before the call
+	ReadOnly bool           // true in the STATICCALL
+}
+
+// newPrecompileEnv returns the environment of the contract call
+func newPrecompileEnv(evm *EVM, contract *Contract) *PrecompileEnv {
+	value := contract.Value()
+	if value == nil {
+		value = amount.NewCoinAmount(0, 0)
+	}
+	return &PrecompileEnv{
+		EVM:      evm,
+		Caller:   contract.Caller(),
+		Address:  contract.Address(),
+		Value:    value,
+		ReadOnly: evm.interpreter.readOnly,
+	}
+}
+
+// State returns the storage value of the Address
+func (env *PrecompileEnv) State(key hash.Hash256) hash.Hash256 {
+	return env.EVM.StateDB.GetState(env.Address, key)
+}
+
+// SetState sets the storage value of the Address
+func (env *PrecompileEnv) SetState(key hash.Hash256, value hash.Hash256) error {
+	if env.ReadOnly {
+		return errWriteProtection
+	}
+	return env.EVM.setState(env.Address, key, value)
+}
+
+// AddLog adds the log of the Address
+func (env *PrecompileEnv) AddLog(topics []hash.Hash256, data []byte) error {
+	if env.ReadOnly {
+		return errWriteProtection
+	}
+	return env.EVM.addLog(env.Address, topics, data)
+}
+
+// Balance returns the balance of the address
+func (env *PrecompileEnv) Balance(addr common.Address) *amount.Amount {
+	return env.EVM.StateDB.GetBalance(addr)
+}
+
+// Transfer transfers the amount from the Address to the address
+// The account of the address should exist because the balance of the nonexistent account cannot be added
+func (env *PrecompileEnv) Transfer(to common.Address, value *amount.Amount) error {
+	if env.ReadOnly && !value.IsZero() {
+		return errWriteProtection
+	}
+	if !env.EVM.StateDB.Exist(to) {
+		return ErrNotExistAccount
+	}
+	if !env.EVM.CanTransfer(env.EVM.StateDB, env.Address, value) {
+		return ErrInsufficientBalance
+	}
+	env.EVM.Transfer(env.EVM.StateDB, env.Address, to, value)
+	return nil
+}
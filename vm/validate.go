@@ -0,0 +1,103 @@
+package vm
+
+import (
+	"fmt"
+)
+
+// designatedInvalid is the opcode which is emitted by the compilers to abort the execution intentionally
+const designatedInvalid = OpCode(0xfe)
+
+// CodePolicy is the validation policy of the code which is deployed by the EVM
+// The code is validated before it runs (the init code) and before it is stored (the runtime code)
+//
+// The opcode checks are applied to the instructions which are reachable in the control flow graph,
+// so the data sections like the solc metadata and the runtime code in the init code are not rejected
+type CodePolicy struct {
+	// MaxInitCodeSize is the maximum size of the init code (EIP-3860, 0 means unlimited)
+	MaxInitCodeSize int
+	// RejectEFPrefix rejects the runtime code which starts with 0xEF (EIP-3541)
+	RejectEFPrefix bool
+	// RejectInvalidOpcodes rejects the runtime code which has the undefined opcodes
+	// except the designated INVALID (0xfe)
+	RejectInvalidOpcodes bool
+	// RejectTruncatedPush rejects the runtime code whose push data is cut by the end of the code
+	RejectTruncatedPush bool
+	// ForbiddenOpcodes are the opcodes which are not allowed in the init code and the runtime code
+	ForbiddenOpcodes []OpCode
+}
+
+// DefaultCodePolicy returns the policy of EIP-3860 and EIP-3541
+func DefaultCodePolicy() *CodePolicy {
+	return &CodePolicy{
+		MaxInitCodeSize: MaxInitCodeSize,
+		RejectEFPrefix:  true,
+	}
+}
+
+// CodeValidationError is the error of the code which is rejected by the CodePolicy
+type CodeValidationError struct {
+	Err    error
+	Offset uint64
+	Op     OpCode
+}
+
+// Error returns the description of the error
+func (e *CodeValidationError) Error() string {
+	if e.Err == ErrInitCodeSizeExceeded || e.Err == ErrInvalidCodePrefix {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v: %s at 0x%06x", e.Err, e.Op.String(), e.Offset)
+}
+
+// Unwrap returns the cause of the error
+func (e *CodeValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateInitCode checks the init code before it runs by the instruction set of the rules
+func (p *CodePolicy) ValidateInitCode(code []byte, rules *Rules) error {
+	if p.MaxInitCodeSize > 0 && len(code) > p.MaxInitCodeSize {
+		return &CodeValidationError{Err: ErrInitCodeSizeExceeded}
+	}
+	if len(p.ForbiddenOpcodes) == 0 {
+		return nil
+	}
+	return p.validateInstructions(code, rules, false)
+}
+
+// ValidateRuntimeCode checks the runtime code before it is stored by the instruction set of the rules
+// The opcodes which are not defined in the fork of the rules are invalid
+func (p *CodePolicy) ValidateRuntimeCode(code []byte, rules *Rules) error {
+	if p.RejectEFPrefix && len(code) > 0 && code[0] == 0xEF {
+		return &CodeValidationError{Err: ErrInvalidCodePrefix, Op: OpCode(code[0])}
+	}
+	if len(p.ForbiddenOpcodes) == 0 && !p.RejectInvalidOpcodes && !p.RejectTruncatedPush {
+		return nil
+	}
+	return p.validateInstructions(code, rules, true)
+}
+
+func (p *CodePolicy) validateInstructions(code []byte, rules *Rules, isRuntime bool) error {
+	forbidden := map[OpCode]bool{}
+	for _, op := range p.ForbiddenOpcodes {
+		forbidden[op] = true
+	}
+	for _, b := range BuildControlFlowGraph(code, rules).Blocks {
+		if !b.Reachable {
+			continue
+		}
+		for i := range b.Instructions {
+			in := &b.Instructions[i]
+			switch {
+			case forbidden[in.Op]:
+				return &CodeValidationError{Err: ErrForbiddenOpcode, Offset: in.Offset, Op: in.Op}
+			case !isRuntime:
+			case p.RejectInvalidOpcodes && !in.Valid && in.Op != designatedInvalid:
+				return &CodeValidationError{Err: ErrInvalidOpcode, Offset: in.Offset, Op: in.Op}
+			case p.RejectTruncatedPush && in.Truncated:
+				return &CodeValidationError{Err: ErrTruncatedPushData, Offset: in.Offset, Op: in.Op}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidOpcodesByFork(t *testing.T) {
+	code := []byte{byte(PUSH1), 1, byte(PUSH1), 1, byte(SHL), byte(STOP)}
+	byzantium, constantinople := NewRules(Byzantium), NewRules(Constantinople)
+
+	if list := Disassemble(code, &byzantium); list[2].Valid {
+		t.Fatal("SHL is valid before the Constantinople")
+	}
+	for _, rules := range []*Rules{&constantinople, nil} {
+		if list := Disassemble(code, rules); !list[2].Valid {
+			t.Fatalf("SHL is invalid by the rules %v", rules)
+		}
+	}
+
+	policy := &CodePolicy{RejectInvalidOpcodes: true}
+	err := policy.ValidateRuntimeCode(code, &byzantium)
+	verr, is := err.(*CodeValidationError)
+	if !is || verr.Err != ErrInvalidOpcode || verr.Offset != 4 {
+		t.Fatalf("got error %v, want the invalid SHL at 4", err)
+	}
+	if !strings.Contains(err.Error(), "SHL at 0x000004") {
+		t.Fatalf("unexpected error message %q", err.Error())
+	}
+	if err := policy.ValidateRuntimeCode(code, &constantinople); err != nil {
+		t.Fatal(err)
+	}
+
+	// the invalid opcode ends the block, so the code after it is not reachable
+	g := BuildControlFlowGraph(code, &byzantium)
+	if len(g.Blocks) != 2 || g.Blocks[1].Reachable {
+		t.Fatalf("the invalid SHL doesn't end the block: %d blocks", len(g.Blocks))
+	}
+}
@@ -0,0 +1,569 @@
+// uint256: Fixed size 256-bit math library
+// Copyright 2018-2020 uint256 Authors
+// SPDX-License-Identifier: BSD-3-Clause
+//
+// The limb arithmetic of the Word (umul, udivrem and the helpers of the Knuth's division)
+// is derived from github.com/holiman/uint256.
+
+package vm
+
+import (
+	"encoding/hex"
+	"math/big"
+	"math/bits"
+	"strings"
+)
+
+// tt256m1 is 2^256 - 1 which is used to truncate a big.Int to 256 bits
+var tt256m1 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// Word is a 256-bit EVM word which is stored in 4 little-endian 64-bit limbs.
+// All arithmetic wraps around modulo 2^256 like the EVM does.
+type Word [4]uint64
+
+// Clear sets z to 0
+func (z *Word) Clear() *Word {
+	*z = Word{}
+	return z
+}
+
+// Set sets z to x
+func (z *Word) Set(x *Word) *Word {
+	*z = *x
+	return z
+}
+
+// SetUint64 sets z to v
+func (z *Word) SetUint64(v uint64) *Word {
+	*z = Word{v}
+	return z
+}
+
+// SetBytes interprets bs as a big-endian unsigned integer and sets z to it.
+// Only the last 32 bytes are used when bs is longer than 32 bytes.
+func (z *Word) SetBytes(bs []byte) *Word {
+	if len(bs) > 32 {
+		bs = bs[len(bs)-32:]
+	}
+	*z = Word{}
+	for i := 0; i < len(bs); i++ {
+		pos := len(bs) - 1 - i
+		z[i/8] |= uint64(bs[pos]) << (uint(i%8) * 8)
+	}
+	return z
+}
+
+// SetFromBig sets z to b modulo 2^256, a negative b is converted to its two's complement
+func (z *Word) SetFromBig(b *big.Int) *Word {
+	return z.SetBytes(new(big.Int).And(b, tt256m1).Bytes())
+}
+
+// ToBig returns z as a big.Int
+func (z *Word) ToBig() *big.Int {
+	bs := z.Bytes32()
+	return new(big.Int).SetBytes(bs[:])
+}
+
+// Bytes32 returns z as a big-endian 32 bytes array
+func (z *Word) Bytes32() [32]byte {
+	var bs [32]byte
+	for i := 0; i < 32; i++ {
+		bs[31-i] = byte(z[i/8] >> (uint(i%8) * 8))
+	}
+	return bs
+}
+
+// Bytes returns z as a big-endian byte slice without leading zeros like big.Int.Bytes
+func (z *Word) Bytes() []byte {
+	bs := z.Bytes32()
+	i := 0
+	for i < len(bs) && bs[i] == 0 {
+		i++
+	}
+	return bs[i:]
+}
+
+// Uint64 returns the lower 64 bits of z
+func (z *Word) Uint64() uint64 {
+	return z[0]
+}
+
+// Int64 returns the lower 64 bits of z as int64
+func (z *Word) Int64() int64 {
+	return int64(z[0])
+}
+
+// IsUint64 reports whether z fits in uint64
+func (z *Word) IsUint64() bool {
+	return z[1]|z[2]|z[3] == 0
+}
+
+// IsZero reports whether z is 0
+func (z *Word) IsZero() bool {
+	return z[0]|z[1]|z[2]|z[3] == 0
+}
+
+// isNeg reports whether z is negative as a two's complement signed integer
+func (z *Word) isNeg() bool {
+	return z[3]>>63 == 1
+}
+
+// BitLen returns the number of bits which are required to represent z
+func (z *Word) BitLen() int {
+	for i := 3; i >= 0; i-- {
+		if z[i] != 0 {
+			return i*64 + bits.Len64(z[i])
+		}
+	}
+	return 0
+}
+
+// Cmp compares z and x as unsigned integers and returns -1, 0 or +1
+func (z *Word) Cmp(x *Word) int {
+	for i := 3; i >= 0; i-- {
+		if z[i] < x[i] {
+			return -1
+		} else if z[i] > x[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Lt reports whether z < x as unsigned integers
+func (z *Word) Lt(x *Word) bool {
+	return z.Cmp(x) < 0
+}
+
+// Gt reports whether z > x as unsigned integers
+func (z *Word) Gt(x *Word) bool {
+	return z.Cmp(x) > 0
+}
+
+// Eq reports whether z == x
+func (z *Word) Eq(x *Word) bool {
+	return *z == *x
+}
+
+// Slt reports whether z < x as signed integers
+func (z *Word) Slt(x *Word) bool {
+	zNeg, xNeg := z.isNeg(), x.isNeg()
+	switch {
+	case zNeg && !xNeg:
+		return true
+	case !zNeg && xNeg:
+		return false
+	default:
+		return z.Lt(x)
+	}
+}
+
+// Sgt reports whether z > x as signed integers
+func (z *Word) Sgt(x *Word) bool {
+	return x.Slt(z)
+}
+
+// Add sets z to x + y
+func (z *Word) Add(x, y *Word) *Word {
+	z.AddOverflow(x, y)
+	return z
+}
+
+// AddOverflow sets z to x + y and reports whether it overflowed
+func (z *Word) AddOverflow(x, y *Word) (*Word, bool) {
+	var carry uint64
+	z[0], carry = bits.Add64(x[0], y[0], 0)
+	z[1], carry = bits.Add64(x[1], y[1], carry)
+	z[2], carry = bits.Add64(x[2], y[2], carry)
+	z[3], carry = bits.Add64(x[3], y[3], carry)
+	return z, carry != 0
+}
+
+// Sub sets z to x - y
+func (z *Word) Sub(x, y *Word) *Word {
+	var borrow uint64
+	z[0], borrow = bits.Sub64(x[0], y[0], 0)
+	z[1], borrow = bits.Sub64(x[1], y[1], borrow)
+	z[2], borrow = bits.Sub64(x[2], y[2], borrow)
+	z[3], _ = bits.Sub64(x[3], y[3], borrow)
+	return z
+}
+
+// Neg sets z to -x
+func (z *Word) Neg(x *Word) *Word {
+	return z.Sub(&Word{}, x)
+}
+
+// Mul sets z to x * y
+func (z *Word) Mul(x, y *Word) *Word {
+	var res Word
+	for j := 0; j < 4; j++ {
+		var carry uint64
+		for i := 0; i+j < 4; i++ {
+			hi, lo := bits.Mul64(x[i], y[j])
+			var c uint64
+			lo, c = bits.Add64(lo, res[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			res[i+j] = lo
+			carry = hi
+		}
+	}
+	*z = res
+	return z
+}
+
+// umul returns the full 512-bit product of x and y in little-endian limbs
+func umul(x, y *Word) [8]uint64 {
+	var res [8]uint64
+	for j := 0; j < 4; j++ {
+		var carry uint64
+		for i := 0; i < 4; i++ {
+			hi, lo := bits.Mul64(x[i], y[j])
+			var c uint64
+			lo, c = bits.Add64(lo, res[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			res[i+j] = lo
+			carry = hi
+		}
+		res[j+4] = carry
+	}
+	return res
+}
+
+// Div sets z to x / y, it sets z to 0 when y is 0
+func (z *Word) Div(x, y *Word) *Word {
+	if y.IsZero() || y.Gt(x) {
+		return z.Clear()
+	}
+	if x.Eq(y) {
+		return z.SetUint64(1)
+	}
+	if x.IsUint64() {
+		return z.SetUint64(x[0] / y[0])
+	}
+	var quot Word
+	udivrem(quot[:], x[:], y)
+	*z = quot
+	return z
+}
+
+// Mod sets z to x % y, it sets z to 0 when y is 0
+func (z *Word) Mod(x, y *Word) *Word {
+	if y.IsZero() || x.Eq(y) {
+		return z.Clear()
+	}
+	if x.Lt(y) {
+		return z.Set(x)
+	}
+	if x.IsUint64() {
+		return z.SetUint64(x[0] % y[0])
+	}
+	var quot Word
+	*z = udivrem(quot[:], x[:], y)
+	return z
+}
+
+// SDiv sets z to x / y as signed integers, it sets z to 0 when y is 0
+func (z *Word) SDiv(x, y *Word) *Word {
+	if y.IsZero() || x.IsZero() {
+		return z.Clear()
+	}
+	// the signs are taken before the Div because z can be x or y
+	neg := x.isNeg() != y.isNeg()
+	var xa, ya Word
+	xa.abs(x)
+	ya.abs(y)
+	z.Div(&xa, &ya)
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+// SMod sets z to x % y as signed integers, the sign of the result follows x
+func (z *Word) SMod(x, y *Word) *Word {
+	if y.IsZero() {
+		return z.Clear()
+	}
+	neg := x.isNeg()
+	var xa, ya Word
+	xa.abs(x)
+	ya.abs(y)
+	z.Mod(&xa, &ya)
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+func (z *Word) abs(x *Word) *Word {
+	if x.isNeg() {
+		return z.Neg(x)
+	}
+	return z.Set(x)
+}
+
+// AddMod sets z to (x + y) % m without the overflow of the sum, it sets z to 0 when m is 0
+func (z *Word) AddMod(x, y, m *Word) *Word {
+	if m.IsZero() {
+		return z.Clear()
+	}
+	var sum Word
+	if _, overflow := sum.AddOverflow(x, y); !overflow {
+		return z.Mod(&sum, m)
+	}
+	u := [5]uint64{sum[0], sum[1], sum[2], sum[3], 1}
+	var quot [5]uint64
+	*z = udivrem(quot[:], u[:], m)
+	return z
+}
+
+// MulMod sets z to (x * y) % m without the overflow of the product, it sets z to 0 when m is 0
+func (z *Word) MulMod(x, y, m *Word) *Word {
+	if m.IsZero() {
+		return z.Clear()
+	}
+	p := umul(x, y)
+	if p[4]|p[5]|p[6]|p[7] == 0 {
+		return z.Mod(&Word{p[0], p[1], p[2], p[3]}, m)
+	}
+	var quot [8]uint64
+	*z = udivrem(quot[:], p[:], m)
+	return z
+}
+
+// Exp sets z to base ** exponent
+func (z *Word) Exp(base, exponent *Word) *Word {
+	res := Word{1}
+	b := *base
+	for i, n := 0, exponent.BitLen(); i < n; i++ {
+		if exponent[i/64]>>(uint(i)%64)&1 == 1 {
+			res.Mul(&res, &b)
+		}
+		b.Mul(&b, &b)
+	}
+	*z = res
+	return z
+}
+
+// SignExtend sets z to num which is sign extended from the (back + 1)th byte
+func (z *Word) SignExtend(back, num *Word) *Word {
+	if !back.IsUint64() || back[0] >= 31 {
+		return z.Set(num)
+	}
+	bit := uint(back[0]*8 + 7)
+	var mask Word
+	mask.Lsh(&Word{1}, bit)
+	mask.Sub(&mask, &Word{1})
+	if num[bit/64]>>(bit%64)&1 == 1 {
+		var inv Word
+		return z.Or(num, inv.Not(&mask))
+	}
+	return z.And(num, &mask)
+}
+
+// Not sets z to ^x
+func (z *Word) Not(x *Word) *Word {
+	z[0], z[1], z[2], z[3] = ^x[0], ^x[1], ^x[2], ^x[3]
+	return z
+}
+
+// And sets z to x & y
+func (z *Word) And(x, y *Word) *Word {
+	z[0], z[1], z[2], z[3] = x[0]&y[0], x[1]&y[1], x[2]&y[2], x[3]&y[3]
+	return z
+}
+
+// Or sets z to x | y
+func (z *Word) Or(x, y *Word) *Word {
+	z[0], z[1], z[2], z[3] = x[0]|y[0], x[1]|y[1], x[2]|y[2], x[3]|y[3]
+	return z
+}
+
+// Xor sets z to x ^ y
+func (z *Word) Xor(x, y *Word) *Word {
+	z[0], z[1], z[2], z[3] = x[0]^y[0], x[1]^y[1], x[2]^y[2], x[3]^y[3]
+	return z
+}
+
+// Byte returns the nth byte of the big-endian representation of z, it returns 0 when n >= 32
+func (z *Word) Byte(n *Word) byte {
+	if !n.IsUint64() || n[0] >= 32 {
+		return 0
+	}
+	pos := 31 - n[0]
+	return byte(z[pos/8] >> ((pos % 8) * 8))
+}
+
+// Lsh sets z to x << n
+func (z *Word) Lsh(x *Word, n uint) *Word {
+	if n >= 256 {
+		return z.Clear()
+	}
+	limbs, shift := int(n/64), n%64
+	var res Word
+	for i := 3; i >= limbs; i-- {
+		res[i] = x[i-limbs] << shift
+		if shift > 0 && i-limbs > 0 {
+			res[i] |= x[i-limbs-1] >> (64 - shift)
+		}
+	}
+	*z = res
+	return z
+}
+
+// Rsh sets z to x >> n with zero fill
+func (z *Word) Rsh(x *Word, n uint) *Word {
+	if n >= 256 {
+		return z.Clear()
+	}
+	limbs, shift := int(n/64), n%64
+	var res Word
+	for i := 0; i+limbs < 4; i++ {
+		res[i] = x[i+limbs] >> shift
+		if shift > 0 && i+limbs < 3 {
+			res[i] |= x[i+limbs+1] << (64 - shift)
+		}
+	}
+	*z = res
+	return z
+}
+
+// SRsh sets z to x >> n with sign extension
+func (z *Word) SRsh(x *Word, n uint) *Word {
+	if !x.isNeg() {
+		return z.Rsh(x, n)
+	}
+	if n >= 256 {
+		return z.Not(&Word{})
+	}
+	var inv Word
+	inv.Not(x)
+	z.Rsh(&inv, n)
+	return z.Not(z)
+}
+
+// String returns z as a decimal string
+func (z *Word) String() string {
+	return z.ToBig().String()
+}
+
+// Hex returns the 0x prefixed hex string of z without leading zeros
+func (z *Word) Hex() string {
+	if z.IsZero() {
+		return "0x0"
+	}
+	return "0x" + strings.TrimLeft(hex.EncodeToString(z.Bytes()), "0")
+}
+
+// udivrem divides u by d and stores the quotient to quot and returns the remainder.
+// It follows the Knuth's division algorithm with 64-bit digits, d must not be 0
+// and quot must have len(u) - len(d) + 1 digits at least.
+func udivrem(quot, u []uint64, d *Word) (rem Word) {
+	dLen := 0
+	for i := len(d) - 1; i >= 0; i-- {
+		if d[i] != 0 {
+			dLen = i + 1
+			break
+		}
+	}
+	uLen := 0
+	for i := len(u) - 1; i >= 0; i-- {
+		if u[i] != 0 {
+			uLen = i + 1
+			break
+		}
+	}
+	if uLen < dLen {
+		copy(rem[:], u)
+		return rem
+	}
+
+	// normalize the divisor so that its top bit is set
+	shift := uint(bits.LeadingZeros64(d[dLen-1]))
+	var dn [4]uint64
+	for i := dLen - 1; i > 0; i-- {
+		dn[i] = d[i]<<shift | d[i-1]>>(64-shift)
+	}
+	dn[0] = d[0] << shift
+
+	var un [9]uint64
+	un[uLen] = u[uLen-1] >> (64 - shift)
+	for i := uLen - 1; i > 0; i-- {
+		un[i] = u[i]<<shift | u[i-1]>>(64-shift)
+	}
+	un[0] = u[0] << shift
+
+	if dLen == 1 {
+		r := un[uLen]
+		for j := uLen - 1; j >= 0; j-- {
+			quot[j], r = bits.Div64(r, un[j], dn[0])
+		}
+		rem[0] = r >> shift
+		return rem
+	}
+
+	udivremKnuth(quot, un[:uLen+1], dn[:dLen])
+	for i := 0; i < dLen-1; i++ {
+		rem[i] = un[i]>>shift | un[i+1]<<(64-shift)
+	}
+	rem[dLen-1] = un[dLen-1] >> shift
+	return rem
+}
+
+// udivremKnuth is the main loop of the Knuth's division algorithm with the normalized divisor
+func udivremKnuth(quot, u, d []uint64) {
+	dh := d[len(d)-1]
+	dl := d[len(d)-2]
+	for j := len(u) - len(d) - 1; j >= 0; j-- {
+		u2 := u[j+len(d)]
+		u1 := u[j+len(d)-1]
+		u0 := u[j+len(d)-2]
+
+		var qhat, rhat uint64
+		if u2 >= dh {
+			qhat = ^uint64(0)
+		} else {
+			qhat, rhat = bits.Div64(u2, u1, dh)
+			ph, pl := bits.Mul64(qhat, dl)
+			if ph > rhat || (ph == rhat && pl > u0) {
+				qhat--
+			}
+		}
+
+		// multiply and subtract, add back when too much is subtracted
+		borrow := subMulTo(u[j:], d, qhat)
+		u[j+len(d)] = u2 - borrow
+		if u2 < borrow {
+			qhat--
+			u[j+len(d)] += addTo(u[j:], d)
+		}
+		quot[j] = qhat
+	}
+}
+
+// subMulTo sets x to x - y * multiplier and returns the borrow
+func subMulTo(x, y []uint64, multiplier uint64) uint64 {
+	var borrow uint64
+	for i := 0; i < len(y); i++ {
+		s, carry1 := bits.Sub64(x[i], borrow, 0)
+		ph, pl := bits.Mul64(y[i], multiplier)
+		t, carry2 := bits.Sub64(s, pl, 0)
+		x[i] = t
+		borrow = ph + carry1 + carry2
+	}
+	return borrow
+}
+
+// addTo sets x to x + y and returns the carry
+func addTo(x, y []uint64) uint64 {
+	var carry uint64
+	for i := 0; i < len(y); i++ {
+		x[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	return carry
+}
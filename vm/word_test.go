@@ -0,0 +1,295 @@
+package vm
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+var (
+	tt256 = new(big.Int).Lsh(big.NewInt(1), 256)
+	tt255 = new(big.Int).Lsh(big.NewInt(1), 255)
+)
+
+// u256 returns x modulo 2^256
+func u256(x *big.Int) *big.Int {
+	return x.And(x, tt256m1)
+}
+
+// s256 returns x as a signed 256-bit integer
+func s256(x *big.Int) *big.Int {
+	if x.Cmp(tt255) < 0 {
+		return new(big.Int).Set(x)
+	}
+	return new(big.Int).Sub(x, tt256)
+}
+
+// testWords returns the edge case words and the random words of the various bit lengths
+func testWords() []*big.Int {
+	list := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(31),
+		big.NewInt(32),
+		big.NewInt(255),
+		big.NewInt(256),
+		new(big.Int).SetUint64(1<<63 - 1),
+		new(big.Int).SetUint64(1 << 63),
+		new(big.Int).SetUint64(^uint64(0)),
+		new(big.Int).Lsh(big.NewInt(1), 64),
+		new(big.Int).Lsh(big.NewInt(1), 128),
+		new(big.Int).Sub(tt255, big.NewInt(1)),
+		new(big.Int).Set(tt255),
+		new(big.Int).Add(tt255, big.NewInt(1)),
+		new(big.Int).Sub(tt256, big.NewInt(2)),
+		new(big.Int).Set(tt256m1),
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 40; i++ {
+		bs := make([]byte, 1+r.Intn(32))
+		r.Read(bs)
+		list = append(list, new(big.Int).SetBytes(bs))
+	}
+	return list
+}
+
+func toWord(b *big.Int) *Word {
+	return new(Word).SetFromBig(b)
+}
+
+type binaryOp struct {
+	name string
+	word func(z, x, y *Word) *Word
+	big  func(x, y *big.Int) *big.Int
+}
+
+var binaryOps = []binaryOp{
+	{"Add", (*Word).Add, func(x, y *big.Int) *big.Int { return u256(new(big.Int).Add(x, y)) }},
+	{"Sub", (*Word).Sub, func(x, y *big.Int) *big.Int { return u256(new(big.Int).Sub(x, y)) }},
+	{"Mul", (*Word).Mul, func(x, y *big.Int) *big.Int { return u256(new(big.Int).Mul(x, y)) }},
+	{"Div", (*Word).Div, func(x, y *big.Int) *big.Int {
+		if y.Sign() == 0 {
+			return new(big.Int)
+		}
+		return new(big.Int).Div(x, y)
+	}},
+	{"Mod", (*Word).Mod, func(x, y *big.Int) *big.Int {
+		if y.Sign() == 0 {
+			return new(big.Int)
+		}
+		return new(big.Int).Mod(x, y)
+	}},
+	{"SDiv", (*Word).SDiv, func(x, y *big.Int) *big.Int {
+		if y.Sign() == 0 {
+			return new(big.Int)
+		}
+		return u256(new(big.Int).Quo(s256(x), s256(y)))
+	}},
+	{"SMod", (*Word).SMod, func(x, y *big.Int) *big.Int {
+		if y.Sign() == 0 {
+			return new(big.Int)
+		}
+		return u256(new(big.Int).Rem(s256(x), s256(y)))
+	}},
+	{"Exp", (*Word).Exp, func(x, y *big.Int) *big.Int { return new(big.Int).Exp(x, y, tt256) }},
+	{"SignExtend", (*Word).SignExtend, func(back, num *big.Int) *big.Int {
+		if back.Cmp(big.NewInt(31)) >= 0 {
+			return new(big.Int).Set(num)
+		}
+		bit := uint(back.Uint64()*8 + 7)
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bit), big.NewInt(1))
+		if num.Bit(int(bit)) == 1 {
+			return u256(new(big.Int).Or(num, new(big.Int).Not(mask)))
+		}
+		return new(big.Int).And(num, mask)
+	}},
+	{"And", (*Word).And, func(x, y *big.Int) *big.Int { return new(big.Int).And(x, y) }},
+	{"Or", (*Word).Or, func(x, y *big.Int) *big.Int { return new(big.Int).Or(x, y) }},
+	{"Xor", (*Word).Xor, func(x, y *big.Int) *big.Int { return new(big.Int).Xor(x, y) }},
+}
+
+func TestWordBinaryOps(t *testing.T) {
+	words := testWords()
+	for _, op := range binaryOps {
+		for _, x := range words {
+			for _, y := range words {
+				want := op.big(x, y)
+				check := func(kind string, got *Word) {
+					if got.ToBig().Cmp(want) != 0 {
+						t.Fatalf("%s(%s) %#x, %#x: got %#x, want %#x", op.name, kind, x, y, got.ToBig(), want)
+					}
+				}
+
+				var z Word
+				check("z", op.word(&z, toWord(x), toWord(y)))
+
+				zx := toWord(x)
+				check("z=x", op.word(zx, zx, toWord(y)))
+
+				zy := toWord(y)
+				check("z=y", op.word(zy, toWord(x), zy))
+
+				if x.Cmp(y) == 0 {
+					zxy := toWord(x)
+					check("z=x=y", op.word(zxy, zxy, zxy))
+				}
+			}
+		}
+	}
+}
+
+func TestWordModOps(t *testing.T) {
+	words := testWords()
+	for _, x := range words {
+		for _, y := range words {
+			for _, m := range words[:12] {
+				var want *big.Int
+				if m.Sign() == 0 {
+					want = new(big.Int)
+				} else {
+					want = new(big.Int).Mod(new(big.Int).Add(x, y), m)
+				}
+				var z Word
+				if got := z.AddMod(toWord(x), toWord(y), toWord(m)); got.ToBig().Cmp(want) != 0 {
+					t.Fatalf("AddMod %#x, %#x, %#x: got %#x, want %#x", x, y, m, got.ToBig(), want)
+				}
+				if m.Sign() != 0 {
+					want = new(big.Int).Mod(new(big.Int).Mul(x, y), m)
+				}
+				if got := z.MulMod(toWord(x), toWord(y), toWord(m)); got.ToBig().Cmp(want) != 0 {
+					t.Fatalf("MulMod %#x, %#x, %#x: got %#x, want %#x", x, y, m, got.ToBig(), want)
+				}
+			}
+		}
+	}
+}
+
+func TestWordShiftOps(t *testing.T) {
+	for _, x := range testWords() {
+		for _, n := range []uint{0, 1, 7, 63, 64, 65, 127, 128, 191, 255, 256, 300} {
+			var z Word
+			want := u256(new(big.Int).Lsh(x, n))
+			if got := z.Lsh(toWord(x), n); got.ToBig().Cmp(want) != 0 {
+				t.Fatalf("Lsh %#x, %d: got %#x, want %#x", x, n, got.ToBig(), want)
+			}
+			want = new(big.Int).Rsh(x, n)
+			if got := z.Rsh(toWord(x), n); got.ToBig().Cmp(want) != 0 {
+				t.Fatalf("Rsh %#x, %d: got %#x, want %#x", x, n, got.ToBig(), want)
+			}
+			want = u256(new(big.Int).Rsh(s256(x), n))
+			if got := z.SRsh(toWord(x), n); got.ToBig().Cmp(want) != 0 {
+				t.Fatalf("SRsh %#x, %d: got %#x, want %#x", x, n, got.ToBig(), want)
+			}
+			zx := toWord(x)
+			want = u256(new(big.Int).Lsh(x, n))
+			if got := zx.Lsh(zx, n); got.ToBig().Cmp(want) != 0 {
+				t.Fatalf("Lsh(z=x) %#x, %d: got %#x, want %#x", x, n, got.ToBig(), want)
+			}
+		}
+	}
+}
+
+func TestWordUnaryOps(t *testing.T) {
+	for _, x := range testWords() {
+		w := toWord(x)
+		if got, want := new(Word).Not(w).ToBig(), u256(new(big.Int).Not(x)); got.Cmp(want) != 0 {
+			t.Fatalf("Not %#x: got %#x, want %#x", x, got, want)
+		}
+		if got, want := new(Word).Neg(w).ToBig(), u256(new(big.Int).Neg(x)); got.Cmp(want) != 0 {
+			t.Fatalf("Neg %#x: got %#x, want %#x", x, got, want)
+		}
+		if got, want := w.BitLen(), x.BitLen(); got != want {
+			t.Fatalf("BitLen %#x: got %d, want %d", x, got, want)
+		}
+		if got := new(Word).SetBytes(w.Bytes()).ToBig(); got.Cmp(x) != 0 {
+			t.Fatalf("SetBytes(Bytes) %#x: got %#x", x, got)
+		}
+		if got, want := w.String(), x.String(); got != want {
+			t.Fatalf("String %#x: got %s, want %s", x, got, want)
+		}
+		bs := make([]byte, 32)
+		copy(bs[32-len(x.Bytes()):], x.Bytes())
+		for n := 0; n < 34; n++ {
+			var want byte
+			if n < 32 {
+				want = bs[n]
+			}
+			if got := w.Byte(new(Word).SetUint64(uint64(n))); got != want {
+				t.Fatalf("Byte %#x, %d: got %#x, want %#x", x, n, got, want)
+			}
+		}
+	}
+}
+
+func TestWordComparisons(t *testing.T) {
+	words := testWords()
+	for _, x := range words {
+		for _, y := range words {
+			wx, wy := toWord(x), toWord(y)
+			if got, want := wx.Cmp(wy), x.Cmp(y); got != want {
+				t.Fatalf("Cmp %#x, %#x: got %d, want %d", x, y, got, want)
+			}
+			if got, want := wx.Lt(wy), x.Cmp(y) < 0; got != want {
+				t.Fatalf("Lt %#x, %#x: got %v", x, y, got)
+			}
+			if got, want := wx.Gt(wy), x.Cmp(y) > 0; got != want {
+				t.Fatalf("Gt %#x, %#x: got %v", x, y, got)
+			}
+			if got, want := wx.Eq(wy), x.Cmp(y) == 0; got != want {
+				t.Fatalf("Eq %#x, %#x: got %v", x, y, got)
+			}
+			if got, want := wx.Slt(wy), s256(x).Cmp(s256(y)) < 0; got != want {
+				t.Fatalf("Slt %#x, %#x: got %v", x, y, got)
+			}
+			if got, want := wx.Sgt(wy), s256(x).Cmp(s256(y)) > 0; got != want {
+				t.Fatalf("Sgt %#x, %#x: got %v", x, y, got)
+			}
+		}
+	}
+}
+
+// benchmarkWordOp compares the Word op with the math/big op which is used before the Word
+func benchmarkWordOp(b *testing.B, op binaryOp, x, y *big.Int) {
+	b.Run("word", func(b *testing.B) {
+		wx, wy := toWord(x), toWord(y)
+		var z Word
+		for i := 0; i < b.N; i++ {
+			op.word(&z, wx, wy)
+		}
+	})
+	b.Run("big", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			op.big(x, y)
+		}
+	})
+}
+
+func BenchmarkWordOps(b *testing.B) {
+	x, _ := new(big.Int).SetString("f123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", 16)
+	y, _ := new(big.Int).SetString("123456789abcdef0123456789abcdef", 16)
+	for _, op := range binaryOps {
+		y := y
+		if op.name == "Exp" || op.name == "SignExtend" {
+			y = big.NewInt(17)
+		}
+		b.Run(op.name, func(b *testing.B) {
+			benchmarkWordOp(b, op, x, y)
+		})
+	}
+	m := new(big.Int).Add(y, big.NewInt(3))
+	b.Run("MulMod", func(b *testing.B) {
+		b.Run("word", func(b *testing.B) {
+			wx, wy, wm := toWord(x), toWord(y), toWord(m)
+			var z Word
+			for i := 0; i < b.N; i++ {
+				z.MulMod(wx, wy, wm)
+			}
+		})
+		b.Run("big", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				new(big.Int).Mod(new(big.Int).Mul(x, y), m)
+			}
+		})
+	})
+}